@@ -0,0 +1,23 @@
+package dataloc_test
+
+import (
+	"fmt"
+	"testing"
+
+	dl "github.com/client9/go-testutil/dataloc"
+)
+
+func TestL_importAlias(t *testing.T) {
+	tests := []struct {
+		name string
+		line int
+	}{
+		{"aliased", __line__()},
+	}
+
+	for _, test := range tests {
+		if got, expected := dl.L(test.name), fmt.Sprintf("%s:%d", "alias_test.go", test.line); got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+	}
+}
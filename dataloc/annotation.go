@@ -0,0 +1,34 @@
+package dataloc
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// AnnotationFull returns a GitHub Actions workflow command annotating the
+// test case identified by its name, in the form of
+//
+//	::error file={file},line={line},col={col}::
+//
+// to which the message should be appended. level is one of "error",
+// "warning" and "notice". The file is relative to $GITHUB_WORKSPACE when it
+// is set. An empty string is returned if the test case cannot be located.
+// The same restrictions as L apply.
+func AnnotationFull(name, level string) string {
+	l, err := locate(name, 2)
+	if err != nil || l == (Location{}) {
+		return ""
+	}
+
+	file := l.File
+	if workspace := os.Getenv("GITHUB_WORKSPACE"); workspace != "" {
+		if abs, err := absPath(file); err == nil {
+			if rel, err := filepath.Rel(workspace, abs); err == nil {
+				file = rel
+			}
+		}
+	}
+
+	return fmt.Sprintf("::%s file=%s,line=%d,col=%d::", level, filepath.ToSlash(file), l.Line, l.Column)
+}
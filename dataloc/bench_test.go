@@ -0,0 +1,127 @@
+package dataloc_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/client9/go-testutil/dataloc"
+)
+
+// Baseline (go test -run - -bench . -benchmem), to compare with when
+// changing how the source is parsed or the tables searched. Except for
+// BenchmarkCache/warm, every op calls Reset and so parses its source anew;
+// BenchmarkSingleLookup parses this package, and grows with its tests.
+// The test cases of a table are read once, but each call still scans them
+// for its name, so that BenchmarkFullTable remains quadratic in time.
+//
+//	BenchmarkSingleLookup         21121070 ns/op   4056737 B/op  93926 allocs/op
+//	BenchmarkFullTable/rows=10       80754 ns/op     28275 B/op    549 allocs/op
+//	BenchmarkFullTable/rows=100     870375 ns/op    150816 B/op   3272 allocs/op
+//	BenchmarkFullTable/rows=1000  42292818 ns/op   1451920 B/op  30305 allocs/op
+//	BenchmarkLargeFile/rows=10       50452 ns/op     20025 B/op    334 allocs/op
+//	BenchmarkLargeFile/rows=100     133671 ns/op     57853 B/op    972 allocs/op
+//	BenchmarkLargeFile/rows=1000    988977 ns/op    409635 B/op   7282 allocs/op
+//	BenchmarkCache/cold             224733 ns/op    101426 B/op   1675 allocs/op
+//	BenchmarkCache/warm              37027 ns/op      2315 B/op     26 allocs/op
+
+func BenchmarkSingleLookup(b *testing.B) {
+	tests := []struct {
+		name string
+	}{
+		{"case0"}, {"case1"}, {"case2"}, {"case3"}, {"case4"},
+		{"case5"}, {"case6"}, {"case7"}, {"case8"}, {"case9"},
+	}
+
+	for i := 0; i < b.N; i++ {
+		dataloc.Reset()
+		for _, test := range tests {
+			if test.name == "case9" {
+				dataloc.L(test.name)
+			}
+		}
+	}
+}
+
+// BenchmarkFullTable resolves a call for each of the rows of a table.
+func BenchmarkFullTable(b *testing.B) {
+	for _, rows := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprintf("rows=%d", rows), func(b *testing.B) {
+			file := writeFixture(b, rows, rows)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				dataloc.Reset()
+				if _, err := dataloc.ResolveFile(file); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkLargeFile resolves a single call to the last row of a table.
+func BenchmarkLargeFile(b *testing.B) {
+	for _, rows := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprintf("rows=%d", rows), func(b *testing.B) {
+			file := writeFixture(b, rows, 1)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				dataloc.Reset()
+				if _, err := dataloc.ResolveFile(file); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// writeFixture writes a test file with a table of rows test cases followed
+// by calls to dataloc.L() with the names of its last calls rows.
+func writeFixture(b *testing.B, rows, calls int) string {
+	b.Helper()
+
+	var buf strings.Builder
+	buf.WriteString("package fixture\n\n")
+	buf.WriteString("import (\n\t\"testing\"\n\n\t\"github.com/client9/go-testutil/dataloc\"\n)\n\n")
+	buf.WriteString("func TestFixture(t *testing.T) {\n\ttests := []struct {\n\t\tname string\n\t\twant int\n\t}{\n")
+	for i := 0; i < rows; i++ {
+		fmt.Fprintf(&buf, "\t\t{%q, %d},\n", fmt.Sprintf("case%d", i), i)
+	}
+	buf.WriteString("\t}\n\n\tfor _, test := range tests {\n\t\tt.Log(dataloc.L(test.name))\n\t}\n\n")
+	for i := rows - calls; i < rows; i++ {
+		fmt.Fprintf(&buf, "\tt.Log(dataloc.L(%q))\n", fmt.Sprintf("case%d", i))
+	}
+	buf.WriteString("}\n")
+
+	file := filepath.Join(b.TempDir(), "fixture_test.go")
+	if err := os.WriteFile(file, []byte(buf.String()), 0o644); err != nil {
+		b.Fatal(err)
+	}
+	return file
+}
+
+// BenchmarkCache resolves a call to a table of 200 test cases, parsing the
+// file every time or once for all, as for the lookups of each of its rows.
+func BenchmarkCache(b *testing.B) {
+	file := writeFixture(b, 200, 1)
+
+	b.Run("cold", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			dataloc.Reset()
+			if _, err := dataloc.ResolveFile(file); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("warm", func(b *testing.B) {
+		dataloc.Reset()
+		for i := 0; i < b.N; i++ {
+			if _, err := dataloc.ResolveFile(file); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
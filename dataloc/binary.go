@@ -0,0 +1,91 @@
+package dataloc
+
+import (
+	"debug/elf"
+	"debug/gosym"
+	"debug/macho"
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// BinaryFallback makes L fall back to the line table embedded in the test
+// binary when the source file of the caller cannot be read, eg. when tests
+// run in a hermetic environment without sources.
+// As the test cases are not available then, the location returned is
+// the one of the dataloc.L() call site rather than of the test case.
+var BinaryFallback = false
+
+// BinaryPath is the binary whose line table is read when BinaryFallback is set.
+// When empty, os.Args[0] is used.
+var BinaryPath = ""
+
+// binaryLoc returns the location of pc according to the line table of the
+// binary, relative to base as relPath does.
+func binaryLoc(base string, pc uintptr) (Location, error) {
+	path := BinaryPath
+	if path == "" {
+		path = os.Args[0]
+	}
+	file, line, err := binaryLine(path, pc)
+	if err != nil {
+		return Location{}, err
+	}
+	file, err = relPath(base, file)
+	if err != nil {
+		return Location{}, err
+	}
+	return Location{File: file, Line: line}, nil
+}
+
+// binaryLine looks up pc, a program counter of the running process, in the
+// line table of the binary at path.
+func binaryLine(path string, pc uintptr) (string, int, error) {
+	pclntab, text, err := readLineTable(path)
+	if err != nil {
+		return "", 0, err
+	}
+	table, err := gosym.NewTable(nil, gosym.NewLineTable(pclntab, text))
+	if err != nil {
+		return "", 0, err
+	}
+
+	// the binary may be loaded at a different address than it is linked at,
+	// so translate pc relative to the entry of its function
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return "", 0, fmt.Errorf("dataloc: no function for pc %#x", pc)
+	}
+	sym := table.LookupFunc(fn.Name())
+	if sym == nil {
+		return "", 0, fmt.Errorf("dataloc: function %s not found in %s", fn.Name(), path)
+	}
+
+	file, line, _ := table.PCToLine(uint64(pc-fn.Entry()) + sym.Entry)
+	if file == "" {
+		return "", 0, fmt.Errorf("dataloc: no line for pc %#x in %s", pc, path)
+	}
+	return file, line, nil
+}
+
+func readLineTable(path string) ([]byte, uint64, error) {
+	if f, err := elf.Open(path); err == nil {
+		defer f.Close()
+		pclntab, text := f.Section(".gopclntab"), f.Section(".text")
+		if pclntab == nil || text == nil {
+			return nil, 0, fmt.Errorf("dataloc: no line table in %s", path)
+		}
+		data, err := pclntab.Data()
+		return data, text.Addr, err
+	}
+	if f, err := macho.Open(path); err == nil {
+		defer f.Close()
+		pclntab, text := f.Section("__gopclntab"), f.Section("__text")
+		if pclntab == nil || text == nil {
+			return nil, 0, fmt.Errorf("dataloc: no line table in %s", path)
+		}
+		data, err := pclntab.Data()
+		return data, text.Addr, err
+	}
+	return nil, 0, fmt.Errorf("dataloc: unsupported binary format: %s", path)
+}
@@ -0,0 +1,279 @@
+package dataloc
+
+import (
+	"go/ast"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// parsedFile is a parsed source file along with the index of its package.
+type parsedFile struct {
+	// srcs are the sources of the files of the directory of the file, keyed
+	// by their names in fset
+	srcs map[string][]byte
+	fset *token.FileSet
+	file *ast.File
+	ix   *index
+	// names are the names in fset of the files of the package of file
+	names map[string]bool
+}
+
+// parsedDir is the Go files of a directory, parsed together so that a table
+// declared in one of them is found from the others, along with the index of
+// each of their packages, eg. foo and foo_test.
+type parsedDir struct {
+	// modTimes are the modification times of the files when they were
+	// read, and of the directory, which changes when a file is added or
+	// removed, keyed by their absolute paths
+	modTimes map[string]time.Time
+	// srcs are the sources of the files, keyed by their names in fset
+	srcs map[string][]byte
+	fset *token.FileSet
+	// files are the files parsed, keyed by their absolute paths
+	files map[string]*ast.File
+	// errs are the errors reading or parsing the files, keyed by their
+	// absolute paths
+	errs map[string]error
+	// ixs are the indexes of the packages, keyed by their names
+	ixs map[string]*index
+	// names are the names in fset of the files of the packages, keyed by
+	// the names of the packages
+	names map[string]map[string]bool
+}
+
+// upToDate reports whether neither the directory of d nor its files have
+// been modified since it was parsed.
+func (d *parsedDir) upToDate() bool {
+	for path, modTime := range d.modTimes {
+		if !modTimeOf(path).Equal(modTime) {
+			return false
+		}
+	}
+	return true
+}
+
+// has reports whether path, an absolute path, was read into d.
+func (d *parsedDir) has(path string) bool {
+	_, ok := d.modTimes[path]
+	return ok
+}
+
+// file returns the parsed file at path, an absolute path, along with the
+// index of its package.
+func (d *parsedDir) file(path string) (*parsedFile, error) {
+	f, ok := d.files[path]
+	if !ok {
+		return nil, d.errs[path]
+	}
+	pkg := f.Name.Name
+	return &parsedFile{srcs: d.srcs, fset: d.fset, file: f, ix: d.ixs[pkg], names: d.names[pkg]}, nil
+}
+
+// modTimeOf returns the modification time of path, or the zero time if it
+// cannot be stat'ed, which is left to readFile to report.
+func modTimeOf(path string) time.Time {
+	if info, err := os.Stat(path); err == nil {
+		return info.ModTime()
+	}
+	return time.Time{}
+}
+
+// cacheKey identifies a parsed directory by its path and the name its
+// positions are reported with, which depends on BasePath and the working
+// directory.
+type cacheKey struct {
+	path string
+	name string
+}
+
+// fileCache holds the files parsed by the lookups of a Finder.
+type fileCache struct {
+	sync.RWMutex
+	dirs map[cacheKey]*parsedDir
+	// parses counts the files parsed, for tests
+	parses int
+}
+
+// cache is the fileCache of the functions of this package, and of the
+// Finders not created by New.
+var cache = &fileCache{}
+
+// loadFile is fileCache.load for the functions of this package.
+func loadFile(file string) (*parsedFile, error) {
+	return cache.load(file, BasePath, nil)
+}
+
+// load returns file parsed, naming it relative to base as relPath does,
+// along with the other files of its directory. The errors reading or parsing
+// the other files are logged to logger, or to Logger if nil.
+func (fc *fileCache) load(file, base string, logger *log.Logger) (*parsedFile, error) {
+	path, err := filepath.Abs(filepath.FromSlash(file))
+	if err != nil {
+		return nil, err
+	}
+	d, err := fc.loadDir(filepath.Dir(path), base, logger, path)
+	if err != nil {
+		return nil, err
+	}
+	return d.file(path)
+}
+
+// loadDir returns the Go files of dir, an absolute path, parsed along with
+// paths, from fc if they were already and have not been modified since.
+// The errors reading or parsing the files other than paths are logged to
+// logger, or to Logger if nil.
+func (fc *fileCache) loadDir(dir, base string, logger *log.Logger, paths ...string) (*parsedDir, error) {
+	name, err := relPath(base, dir)
+	if err != nil {
+		return nil, err
+	}
+	key := cacheKey{dir, name}
+
+	fc.RLock()
+	d, ok := fc.dirs[key]
+	fc.RUnlock()
+	if ok && d.upToDate() {
+		missing := false
+		for _, path := range paths {
+			missing = missing || !d.has(path)
+		}
+		if !missing {
+			return d, nil
+		}
+	}
+
+	d = parseDir(dir, base, logger, paths)
+	fc.Lock()
+	defer fc.Unlock()
+	if fc.dirs == nil {
+		fc.dirs = map[cacheKey]*parsedDir{}
+	}
+	fc.parses += len(d.files) + len(d.errs)
+	fc.dirs[key] = d
+	return d, nil
+}
+
+// parseDir parses the Go files of dir along with paths, naming them relative
+// to base, and indexes them by package. The files which cannot be read or
+// parsed are recorded in errs, and logged to logger unless in paths.
+func parseDir(dir, base string, logger *log.Logger, paths []string) *parsedDir {
+	d := &parsedDir{
+		modTimes: map[string]time.Time{},
+		srcs:     map[string][]byte{},
+		fset:     token.NewFileSet(),
+		files:    map[string]*ast.File{},
+		errs:     map[string]error{},
+		ixs:      map[string]*index{},
+		names:    map[string]map[string]bool{},
+	}
+
+	d.modTimes[dir] = modTimeOf(dir)
+	var files []string
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		logTo(logger, "could not read directory %s: %v", dir, err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".go") {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	wanted := map[string]bool{}
+	for _, path := range paths {
+		wanted[path] = true
+		if !containsString(files, path) {
+			files = append(files, path)
+		}
+	}
+
+	packages := map[string]map[string]*ast.File{}
+	var order []*ast.File
+	for _, path := range files {
+		d.modTimes[path] = modTimeOf(path)
+		src, err := readFile(path)
+		if err != nil {
+			d.errs[path] = err
+			if !wanted[path] {
+				logTo(logger, "could not read %s: %v", path, err)
+			}
+			continue
+		}
+		f, err := parseFile(d.fset, base, path, src)
+		if err != nil {
+			d.errs[path] = err
+			if !wanted[path] {
+				logTo(logger, "could not parse %s: %v", path, err)
+			}
+			continue
+		}
+		d.files[path] = f
+		d.srcs[d.fset.File(f.Pos()).Name()] = src
+		if packages[f.Name.Name] == nil {
+			packages[f.Name.Name] = map[string]*ast.File{}
+		}
+		packages[f.Name.Name][path] = f
+		order = append(order, f)
+	}
+
+	for pkg, pkgFiles := range packages {
+		// resolve the identifiers declared in another file of the package;
+		// the errors are of the identifiers declared in other packages
+		_, _ = ast.NewPackage(d.fset, pkgFiles, nil, nil)
+
+		ix := newIndex()
+		names := map[string]bool{}
+		for _, f := range order {
+			if f.Name.Name == pkg {
+				ix.add(f)
+				names[d.fset.File(f.Pos()).Name()] = true
+			}
+		}
+		d.ixs[pkg] = ix
+		d.names[pkg] = names
+	}
+	return d
+}
+
+func containsString(list []string, s string) bool {
+	for _, elem := range list {
+		if elem == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Preload parses the given source files up front, eg. in TestMain, so that
+// the lookups of their test cases do not parse them again. If no file is
+// given, the source file of the caller is parsed.
+func Preload(files ...string) error {
+	if len(files) == 0 {
+		_, file, _, _ := runtime.Caller(1)
+		files = []string{file}
+	}
+	for _, file := range files {
+		if _, err := loadFile(file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reset clears the files parsed by the lookups or Preload, so that the next
+// lookup parses its source anew, eg. after the source has changed.
+func Reset() {
+	cache.reset()
+}
+
+// reset clears the files of fc.
+func (fc *fileCache) reset() {
+	fc.Lock()
+	defer fc.Unlock()
+	fc.dirs = nil
+}
@@ -3,22 +3,30 @@
 package dataloc
 
 import (
+	"errors"
 	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"go/types"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strconv"
+	"strings"
+	"sync"
+	"unicode"
 )
 
 // L returns the source code location of the test case identified by its name.
 // It attempts runtime source code analysis to find the location
 // by using the expression passed to dataloc.L().
 // So some restrictions apply:
-//   - The function must be invoked as "dataloc.L".
+//   - The function must be invoked through the name the file imports this
+//     package as: "dataloc.L", or eg. "dl.L" under the alias dl, or "L" when
+//     dot-imported, unless the calling package declares an L of its own.
 //   - The argument must be an expression of the form "dataloc.L(testcase.key)"
 //     , where "testcase" is a variable declared as "for _, testcase := range testcases"
 //     , and "testcases" is a slice of a struct type
@@ -27,67 +35,531 @@ import (
 //     , where key is a variable declared as "for key, value := range testcases"
 //     , and "testcases" is a map of string to any type
 //     , and "key" is the string which is passed to L().
+//   - or "dataloc.L(prefix + testcase.key + suffix)"
+//     , where "prefix" and "suffix" are string literals, either of which may be omitted
+//     , and the test case is located by the name with them stripped.
+//   - or "dataloc.L(fmt.Sprintf(format, testcase.key, i))"
+//     , where "i" is the index declared as "for i, testcase := range testcases"
+//     . This is a heuristic which assumes "i" to be the position of the test case in the literal of "testcases".
 //
 // See Example.
 func L(name string) string {
+	return LSkip(3, name)
+}
+
+// LOrEmpty is like L but returns an empty string, instead of "(unknown)",
+// when the test case cannot be located.
+func LOrEmpty(name string) string {
+	l, err := locate(name, 2)
+	if err != nil || l == (Location{}) {
+		return ""
+	}
+	return l.String()
+}
+
+// Msg returns a message locating the test case identified by its name, in
+// the form of
+//
+//	case "name" @ file:line
+//
+// to be passed as the optional message of testify assertions, eg.
+//
+//	assert.Equal(t, testcase.want, got, dataloc.Msg(testcase.name))
+//
+// The same restrictions as L apply.
+func Msg(name string) string {
 	s, _ := loc(name, 2)
+	if s == "" {
+		s = "(unknown)"
+	}
+	return fmt.Sprintf("case %q @ %s", name, s)
+}
+
+// LErr is like L but also returns the reason why the test case cannot be
+// located: ErrNotFound if the source was parsed but no test case has the
+// name, or the error reading or parsing the source.
+func LErr(name string) (string, error) {
+	return loc(name, 2)
+}
+
+// LSkip is like L but locates the test case from the call at the caller
+// frame given by skip, counted as runtime.Caller does from the frame looking
+// up the test case: 0 is that frame, 1 is LSkip and 2 its caller, so that
+// LSkip(2, name) is the same as L(name).
+// The call at that frame must still be one of the functions of this package
+// taking the name, eg. "dataloc.LSkip(2, testcase.name)".
+func LSkip(skip int, name string) string {
+	s, _ := loc(name, skip)
 	return s
 }
 
+// LInt is like L but for a test case identified by an integer, the key of a
+// map such as "map[int]testcase{...}", eg. "dataloc.LInt(id)" where id is
+// declared as "for id, testcase := range testcases".
+func LInt(key int) string {
+	return LSkip(3, strconv.Itoa(key))
+}
+
+// LField is like L but matches name against the field fieldName of the test
+// cases, in the tables ranged over by the loops enclosing the call, innermost
+// first, eg.
+//
+//	for _, testcase := range testcases {
+//		n := testcase.desc
+//		t.Log(dataloc.LField("desc", n))
+//	}
+//
+// The field given takes precedence over any inferred from the argument,
+// which may then have any form. For a map, the field is that of its values.
+func LField(fieldName, name string) string {
+	c, err := callerAt(2)
+	if err != nil {
+		return ""
+	}
+
+	match := c.ix.stringMatcher(name)
+	for _, table := range c.enclosingTables() {
+		items := c.ix.mapValueItems(table, fieldName)
+		if items == nil {
+			items = c.ix.tableItems(table, fieldName)
+		}
+		for _, item := range items {
+			if !match(item) {
+				continue
+			}
+			l, err := c.location(item.node)
+			if err != nil {
+				return ""
+			}
+			return l.String()
+		}
+	}
+	return "(unknown)"
+}
+
 func L3(name string) string {
-	s, _ := loc(name, 3)
-	return s
+	return LSkip(4, name)
 }
 
 func L4(name string) string {
-	s, _ := loc(name, 4)
-	return s
+	return LSkip(5, name)
 }
 
 func L5(name string) string {
-	s, _ := loc(name, 5)
-	return s
+	return LSkip(6, name)
 }
 
 func L6(name string) string {
-	s, _ := loc(name, 6)
-	return s
+	return LSkip(7, name)
 }
 
 func loc(value string, step int) (string, error) {
-	_, file, line, _ := runtime.Caller(step)
-	log.Printf("Caller Step %d: %s %d", step, file, line)
-	cwd, err := os.Getwd()
+	l, err := locate(value, step+1)
 	if err != nil {
 		return "", err
 	}
-	file, err = filepath.Rel(cwd, file)
+	if l == (Location{}) {
+		return "(unknown)", fmt.Errorf("%w: %q", ErrNotFound, value)
+	}
+	return l.String(), nil
+}
+
+// nameFuncs are the functions of this package taking the name of a test case,
+// whose calls are looked for at the caller line, along with the position of
+// the name in their arguments.
+var nameFuncs = map[string]int{
+	"L":              0,
+	"AnnotationFull": 0,
+	"LocateNth":      0,
+	"LOrEmpty":       0,
+	"LineText":       0,
+	"Msg":            0,
+	"LocateValue":    0,
+	"EditorURL":      0,
+	"LErr":           0,
+	"LSkip":          1,
+	"Find":           0,
+	"Has":            0,
+	"LInt":           0,
+	"Candidates":     0,
+	"FindMatch":      0,
+	"SARIFResult":    0,
+	"Explain":        0,
+	"Locate":         1,
+}
+
+// isNameFuncCall matches a call to one of nameFuncs, eg. "dataloc.L(...)"
+// where pkg is the name the file imports this package as, given by
+// importName, and returns it along with its argument giving the name.
+func isNameFuncCall(n ast.Node, pkg string) (*ast.CallExpr, ast.Expr, bool) {
+	call, ok := n.(*ast.CallExpr)
+	if !ok || pkg == "" {
+		return nil, nil, false
+	}
+
+	var name string
+	if pkg == "." {
+		// L(...) with the package dot-imported, unless L is declared
+		// in the file or its package
+		ident, ok := call.Fun.(*ast.Ident)
+		if !ok || ident.Obj != nil {
+			return nil, nil, false
+		}
+		name = ident.Name
+	} else {
+		ident, sel, ok := isSelector(call.Fun)
+		if !ok || ident.Name != pkg {
+			return nil, nil, false
+		}
+		name = sel
+	}
+
+	if i, ok := nameFuncs[name]; ok && i < len(call.Args) {
+		return call, call.Args[i], true
+	}
+	return nil, nil, false
+}
+
+// importName returns the name f imports this package as, either its alias,
+// "." if dot-imported, or "dataloc", or "" if f does not import it.
+func importName(f *ast.File) string {
+	for _, spec := range f.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil || !strings.HasSuffix(path, "/dataloc") {
+			continue
+		}
+		if spec.Name != nil {
+			return spec.Name.Name
+		}
+		return "dataloc"
+	}
+	return ""
+}
+
+// locate returns the location of the test case named value, looked up by
+// the call at the caller frame given by step. The zero Location is
+// returned if no test case is found.
+func locate(value string, step int) (Location, error) {
+	return defaultFinder().locate(value, step+1)
+}
+
+// caller is the parsed source file of a caller frame.
+type caller struct {
+	pc   uintptr
+	line int
+	// srcs are the sources of the files of the package, keyed by their
+	// names in fset
+	srcs map[string][]byte
+	fset *token.FileSet
+	file *ast.File
+	ix   *index
+	// names are the names in fset of the files of the package of file
+	names map[string]bool
+	// anyCall makes the names be looked up by the arguments of any call at
+	// the line, rather than of the calls to nameFuncs only
+	anyCall bool
+	// pkg, if not empty, is the name nameFuncs are called with, instead of
+	// the name the file imports this package as
+	pkg string
+	// funcName, if not empty, is the name of a function or method also
+	// looked for, taking the name as its first argument
+	funcName string
+	// finder makes the calls to the Find method of a Finder looked for
+	finder bool
+}
+
+// callerAt parses the source file of the caller frame given by step, counted
+// as runtime.Caller does, with the configuration of the package variables.
+// If the source file cannot be read or parsed, the error is returned along
+// with a caller holding only pc.
+func callerAt(step int) (*caller, error) {
+	return defaultFinder().parseCaller(step + 1)
+}
+
+// parseCaller is callerAt with the configuration of f.
+func (f *Finder) parseCaller(step int) (*caller, error) {
+	pc, file, line, ok := runtime.Caller(step)
+	if !ok {
+		// eg. a skip beyond the outermost frame given to LSkip
+		return nil, fmt.Errorf("dataloc: could not recover caller at skip %d", step)
+	}
+	return f.newCaller(pc, file, line)
+}
+
+// newCaller parses file, the source file of the frame of pc at line, into
+// the cache of f.
+func (f *Finder) newCaller(pc uintptr, file string, line int) (*caller, error) {
+	if StrictModule {
+		if err := checkInModule(file); err != nil {
+			return nil, err
+		}
+	}
+	fc := f.cache
+	if fc == nil {
+		fc = cache
+	}
+	p, err := fc.load(file, f.BasePath, f.Logger)
 	if err != nil {
-		return "", err
+		return &caller{pc: pc, line: line}, err
+	}
+
+	ix := *p.ix
+	ix.finder = f
+	return &caller{pc: pc, line: line, srcs: p.srcs, fset: p.fset, file: p.file, ix: &ix, names: p.names}, nil
+}
+
+// lookups returns the lookups of the calls to nameFuncs at the caller line.
+func (c *caller) lookups() []lookup {
+	pkg := importName(c.file)
+	if c.pkg != "" {
+		pkg = c.pkg
+	}
+	var lookups []lookup
+	ast.Inspect(c.file, func(n ast.Node) bool {
+		if n == nil {
+			return false
+		}
+
+		pos := c.fset.Position(n.Pos())
+		if pos.Line != c.line {
+			return true
+		}
+
+		if c.anyCall {
+			if call, ok := n.(*ast.CallExpr); ok {
+				for _, arg := range call.Args {
+					if l, ok := c.ix.lookupOf(arg); ok {
+						l.call, l.arg = call, arg
+						lookups = append(lookups, l)
+					}
+				}
+			}
+		} else if call, arg, ok := isNameFuncCall(n, pkg); ok {
+			if l, ok := c.ix.lookupOf(arg); ok {
+				l.call, l.arg = call, arg
+				lookups = append(lookups, l)
+			}
+		} else if call, arg, ok := c.isFinderCall(n, pkg); ok {
+			if l, ok := c.ix.lookupOf(arg); ok {
+				l.call, l.arg = call, arg
+				lookups = append(lookups, l)
+			}
+		}
+
+		return true
+	})
+	return lookups
+}
+
+// find returns the first test case named value, looked up by the calls at
+// the caller line, or nil if none is found.
+// As several calls may share the line, the name decides which of them is
+// the caller; an error of a lookup is returned only if none matches.
+func (c *caller) find(value string) (ast.Node, error) {
+	item, err := c.findItem(value)
+	return item.node, err
+}
+
+// findItem is like find but returns the test case along with its name.
+func (c *caller) findItem(value string) (caseItem, error) {
+	item, _, err := c.findMatch(value)
+	return item, err
+}
+
+// findMatch is like findItem but also returns the lookup which found the
+// test case.
+func (c *caller) findMatch(value string) (caseItem, lookup, error) {
+	var err error
+	for _, l := range c.lookups() {
+		if l.err != nil {
+			if err == nil {
+				err = l.err
+			}
+			continue
+		}
+		if items := c.ix.findItems(l, value); len(items) > 0 {
+			return items[0], l, nil
+		}
+	}
+	return caseItem{}, lookup{}, err
+}
+
+// location returns the location of node, a test case found in the caller
+// file or in another file of its package.
+func (c *caller) location(node ast.Node) (Location, error) {
+	// the calls are only looked for in the file reported by runtime.Caller,
+	// and the test case must come from the files parsed along with it
+	file := c.fset.File(c.file.Pos()).Name()
+	pos := c.fset.Position(node.Pos())
+	if !pos.IsValid() {
+		return Location{}, fmt.Errorf("dataloc: test case is outside of the package of the caller file %s", file)
+	}
+	// a position in another file than the caller's, eg. of another package
+	// in the same directory such as foo_test, is a mistake unless the file
+	// is of the package of the caller
+	if pos.Filename != file && !c.names[pos.Filename] {
+		return Location{}, fmt.Errorf("dataloc: test case at %s is outside of the package of the caller file %s", pos, file)
+	}
+
+	return positionToLocation(pos), nil
+}
+
+var readFile = os.ReadFile
+
+// parseFile parses src, the content of file, into fset, naming it relative
+// to base as relPath does so that the positions are reported in that form.
+func parseFile(fset *token.FileSet, base, file string, src []byte) (*ast.File, error) {
+	file, err := relPath(base, file)
+	if err != nil {
+		return nil, err
+	}
+	return parser.ParseFile(fset, file, src, 0)
+}
+
+// Location is a position in a source file.
+type Location struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// String returns the location in the form of "file:line".
+func (l Location) String() string {
+	return fmt.Sprintf("%s:%d", l.File, l.Line)
+}
+
+// absPath returns the absolute path of file, a path relative to BasePath
+// or the current working directory as found in a Location.
+func absPath(file string) (string, error) {
+	if filepath.IsAbs(file) {
+		return file, nil
+	}
+	if BasePath != "" {
+		return filepath.Join(BasePath, file), nil
 	}
+	return filepath.Abs(file)
+}
+
+func positionToLocation(pos token.Position) Location {
+	return Location{File: pos.Filename, Line: pos.Line, Column: pos.Column}
+}
+
+// ResolveSymlinks makes L evaluate symbolic links in both the caller file
+// and the current working directory before computing the relative path
+// of the location. It is off by default as it costs extra system calls.
+var ResolveSymlinks = false
 
-	fset := token.NewFileSet()
-	f, err := parser.ParseFile(fset, file, nil, 0)
+// BasePath is the directory the returned locations are relative to.
+// When empty, the current working directory is used.
+var BasePath = ""
+
+// relPath returns file relative to base, eg. BasePath, or to the current
+// working directory if base is empty, or absolute if it cannot be made
+// relative.
+func relPath(base, file string) (string, error) {
+	if base == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		base = cwd
+	}
+	file, err := filepath.Abs(file)
 	if err != nil {
 		return "", err
 	}
+	if ResolveSymlinks {
+		if base, err = filepath.EvalSymlinks(base); err != nil {
+			return "", err
+		}
+		if file, err = filepath.EvalSymlinks(file); err != nil {
+			return "", err
+		}
+	}
+	rel, err := filepath.Rel(base, file)
+	if err != nil {
+		// eg. file is on another volume than base on Windows, in which case
+		// the absolute path still locates it
+		return file, nil
+	}
+	return rel, nil
+}
 
+// index holds the declarations of a parsed file that are needed to resolve
+// a test case table from the expression passed to dataloc.L().
+type index struct {
 	// [ t ↦ expr ] for "type t struct{ ... }"
-	objToTypeDecl := make(map[*ast.Object]ast.Expr)
-	// [ v ↦ expr ] for "v := ..."
-	objToVarInit := make(map[*ast.Object]ast.Expr)
+	objToTypeDecl map[*ast.Object]ast.Expr
+	// [ v ↦ expr ] for "v := ..."; the parser resolves identifiers in the
+	// scope of their block, so that a variable shadowing another one in an
+	// inner block has its own object
+	objToVarInit map[*ast.Object]ast.Expr
 	// [ v ↦ expr ] for "for k, v := range expr"
-	objToRangeExprForValue := make(map[*ast.Object]ast.Expr)
+	objToRangeExprForValue map[*ast.Object]ast.Expr
 	// [ k ↦ expr ] for "for k, v := range expr"
-	objToRangeExprForKey := make(map[*ast.Object]ast.Expr)
+	objToRangeExprForKey map[*ast.Object]ast.Expr
+	// [ c ↦ n ] for "const c = n" of integers, including iota
+	objToConstInt map[*ast.Object]int64
+	// [ c ↦ expr ] for "const c = expr", eg. of strings
+	objToConstValue map[*ast.Object]ast.Expr
+	// [ v ↦ type ] for "var v type"
+	objToVarType map[*ast.Object]ast.Expr
+	// [ v ↦ exprs ] for "v = append(v, exprs...)"
+	objToAppends map[*ast.Object][]ast.Expr
+	// [ v ↦ i: expr ] for "v[i] = expr"
+	objToIndexAssigns map[*ast.Object][]*ast.KeyValueExpr
+	// [ m ↦ decls ] for "func (recv t) m() ..."
+	methods map[string][]*ast.FuncDecl
+	// [ f ↦ exprs ] for "x.f = expr", eg. "s.cases = ..." in the SetupTest
+	// method of a suite
+	fieldAssigns map[string][]ast.Expr
+	// [ name ↦ kind ] for the declarations at the top level of the package,
+	// eg. of a function declared in another file
+	decls map[string]ast.ObjKind
+	// tables caches tableItems
+	tables *itemsCache
+	// finder, if not nil, is the Finder of the lookup, whose configuration
+	// applies instead of the package variables; it is set on a copy of the
+	// cached index, which shares its maps
+	finder *Finder
+}
+
+// newIndex indexes files, the files of a package, whose identifiers refer
+// to the declarations of one another.
+func newIndex(files ...*ast.File) *index {
+	ix := &index{
+		objToTypeDecl:          make(map[*ast.Object]ast.Expr),
+		objToVarInit:           make(map[*ast.Object]ast.Expr),
+		objToRangeExprForValue: make(map[*ast.Object]ast.Expr),
+		objToRangeExprForKey:   make(map[*ast.Object]ast.Expr),
+		objToConstInt:          make(map[*ast.Object]int64),
+		objToConstValue:        make(map[*ast.Object]ast.Expr),
+		objToVarType:           make(map[*ast.Object]ast.Expr),
+		objToAppends:           make(map[*ast.Object][]ast.Expr),
+		objToIndexAssigns:      make(map[*ast.Object][]*ast.KeyValueExpr),
+		methods:                make(map[string][]*ast.FuncDecl),
+		fieldAssigns:           make(map[string][]ast.Expr),
+		decls:                  make(map[string]ast.ObjKind),
+		tables:                 &itemsCache{items: make(map[itemsKey][]caseItem)},
+	}
+	for _, f := range files {
+		ix.add(f)
+	}
+	return ix
+}
 
+// add indexes the declarations of f.
+func (ix *index) add(f *ast.File) {
+	for name, obj := range f.Scope.Objects {
+		ix.decls[name] = obj.Kind
+	}
 	ast.Inspect(f, func(n ast.Node) bool {
 		if rangeStmt, ok := n.(*ast.RangeStmt); ok {
 			if ident, ok := rangeStmt.Value.(*ast.Ident); ok {
-				objToRangeExprForValue[ident.Obj] = rangeStmt.X
+				ix.objToRangeExprForValue[ident.Obj] = rangeStmt.X
 			}
 			if ident, ok := rangeStmt.Key.(*ast.Ident); ok {
-				objToRangeExprForKey[ident.Obj] = rangeStmt.X
+				ix.objToRangeExprForKey[ident.Obj] = rangeStmt.X
 			}
 		} else if decl, ok := n.(ast.Decl); ok {
 			if genDecl, ok := decl.(*ast.GenDecl); ok {
@@ -95,8 +567,31 @@ func loc(value string, step int) (string, error) {
 					for _, spec := range genDecl.Specs {
 						if valueSpec, ok := spec.(*ast.ValueSpec); ok {
 							for i, name := range valueSpec.Names {
-								if i < len(valueSpec.Values)-1 {
-									objToVarInit[name.Obj] = valueSpec.Values[i]
+								// every name given a value, the last one
+								// included, eg. b of "var a, b = x, y"
+								if i < len(valueSpec.Values) {
+									ix.objToVarInit[name.Obj] = valueSpec.Values[i]
+								}
+								if valueSpec.Type != nil {
+									ix.objToVarType[name.Obj] = valueSpec.Type
+								}
+							}
+						}
+					}
+				} else if genDecl.Tok == token.CONST {
+					// an omitted value repeats the previous one with the next iota
+					var values []ast.Expr
+					for iota, spec := range genDecl.Specs {
+						if valueSpec, ok := spec.(*ast.ValueSpec); ok {
+							if len(valueSpec.Values) > 0 {
+								values = valueSpec.Values
+							}
+							for i, name := range valueSpec.Names {
+								if i < len(values) {
+									if n, ok := ix.evalInt(values[i], int64(iota)); ok {
+										ix.objToConstInt[name.Obj] = n
+									}
+									ix.objToConstValue[name.Obj] = values[i]
 								}
 							}
 						}
@@ -104,210 +599,1898 @@ func loc(value string, step int) (string, error) {
 				} else if genDecl.Tok == token.TYPE {
 					for _, spec := range genDecl.Specs {
 						if typeSpec, ok := spec.(*ast.TypeSpec); ok {
-							objToTypeDecl[typeSpec.Name.Obj] = typeSpec.Type
+							ix.objToTypeDecl[typeSpec.Name.Obj] = typeSpec.Type
 						}
 					}
 				}
+			} else if funcDecl, ok := decl.(*ast.FuncDecl); ok && funcDecl.Recv != nil {
+				ix.methods[funcDecl.Name.Name] = append(ix.methods[funcDecl.Name.Name], funcDecl)
 			}
 		} else if assignStmt, ok := n.(*ast.AssignStmt); ok {
 			for i, expr := range assignStmt.Lhs {
 				if ident, ok := expr.(*ast.Ident); ok {
-					if len(assignStmt.Lhs) == len(assignStmt.Rhs) {
-						objToVarInit[ident.Obj] = assignStmt.Rhs[i]
+					if len(assignStmt.Lhs) == len(assignStmt.Rhs) && isAppendTo(assignStmt.Rhs[i], ident) {
+						// v = append(v, ...) adds test cases to v, eg. in init()
+						call := assignStmt.Rhs[i].(*ast.CallExpr)
+						ix.objToAppends[ident.Obj] = append(ix.objToAppends[ident.Obj], call.Args[1:]...)
+					} else if len(assignStmt.Lhs) == len(assignStmt.Rhs) {
+						ix.objToVarInit[ident.Obj] = assignStmt.Rhs[i]
 					} else if len(assignStmt.Rhs) == 1 {
-						objToVarInit[ident.Obj] = assignStmt.Rhs[0]
+						ix.objToVarInit[ident.Obj] = assignStmt.Rhs[0]
 					} else {
 						debugf("unreachable: len(assignStmt.Lhs)=%d, len(assignStmt.Rhs)=%d", len(assignStmt.Lhs), len(assignStmt.Rhs))
 					}
+				} else if index, ok := expr.(*ast.IndexExpr); ok && assignStmt.Tok == token.ASSIGN && len(assignStmt.Lhs) == len(assignStmt.Rhs) {
+					// v[i] = ... sets a test case of v, eg. after "v := make([]testcase, n)"
+					if ident, ok := index.X.(*ast.Ident); ok {
+						kv := &ast.KeyValueExpr{Key: index.Index, Colon: index.Lbrack, Value: assignStmt.Rhs[i]}
+						ix.objToIndexAssigns[ident.Obj] = append(ix.objToIndexAssigns[ident.Obj], kv)
+					}
+				} else if sel, ok := expr.(*ast.SelectorExpr); ok && assignStmt.Tok == token.ASSIGN && len(assignStmt.Lhs) == len(assignStmt.Rhs) {
+					// x.f = ... sets a field, eg. the test cases of a suite
+					ix.fieldAssigns[sel.Sel.Name] = append(ix.fieldAssigns[sel.Sel.Name], assignStmt.Rhs[i])
 				}
 			}
 		}
 
 		return true
 	})
+}
 
-	loc := "(unknown)"
-	ast.Inspect(f, func(n ast.Node) bool {
-		if n == nil {
-			return false
-		}
+// find returns the first test case of the lookup whose name is value.
+func (ix *index) find(l lookup, value string) ast.Node {
+	if nodes := ix.findAll(l, value); len(nodes) > 0 {
+		return nodes[0]
+	}
+	return nil
+}
 
-		pos := fset.Position(n.Pos())
-		if pos.Line != line {
-			return true
-		}
+// findAll returns all the test cases of the lookup whose name is value,
+// in the order of the source.
+func (ix *index) findAll(l lookup, value string) []ast.Node {
+	var nodes []ast.Node
+	for _, item := range ix.findItems(l, value) {
+		nodes = append(nodes, item.node)
+	}
+	return nodes
+}
 
-		// for example:
-		//   testcases := []struct{}{...}
-		//   for _, testdata := range testcases {
-		//     dataloc.L(testdata.name)
-		//   }
-		if call, ok := isMethodCall(n, "dataloc", "L"); ok {
-			arg := call.Args[0]
-			// ident = testdata, key = name
-			if ident, key, ok := isSelector(arg); ok {
-				// expr = testcases
-				if expr, ok := objToRangeExprForValue[ident.Obj]; ok {
-					if testcasesIdent, ok := expr.(*ast.Ident); ok {
-						// testcasesExpr = []struct{}{...}
-						testcasesExpr := objToVarInit[testcasesIdent.Obj]
-						node := findTestCaseItem(testcasesExpr, key, value, objToTypeDecl)
-						if node != nil {
-							pos := fset.Position(node.Pos())
-							loc = fmt.Sprintf("%s:%d", pos.Filename, pos.Line)
-							return false
-						}
-					}
-				}
-			} else if ident, ok := arg.(*ast.Ident); ok {
-				// for k, v := range testcases {
-				//   dataloc.L(k)
-				// }
-				if expr, ok := objToRangeExprForKey[ident.Obj]; ok {
-					if testcasesIdent, ok := expr.(*ast.Ident); ok {
-						testcasesExpr := objToVarInit[testcasesIdent.Obj]
-						node := findTestCaseItem(testcasesExpr, ident.Name, value, objToTypeDecl)
-						if node != nil {
-							pos := fset.Position(node.Pos())
-							loc = fmt.Sprintf("%s:%d", pos.Filename, pos.Line)
-							return false
-						}
-					}
-				}
-			}
+// findItems returns all the test cases of the lookup whose name is value,
+// in the order of the source.
+func (ix *index) findItems(l lookup, value string) []caseItem {
+	var items []caseItem
+	match := l.match(value)
+	for _, item := range ix.items(l) {
+		if match(item) {
+			items = append(items, item)
 		}
-
-		return true
-	})
-
-	return loc, nil
+	}
+	return items
 }
 
-func isMethodCall(n ast.Node, obj, fun string) (*ast.CallExpr, bool) {
-	if call, ok := n.(*ast.CallExpr); ok {
-		if ident, name, ok := isSelector(call.Fun); ok {
-			if ident.Name == obj && name == fun {
-				return call, true
-			}
-		}
+// items returns the test cases of the lookup.
+func (ix *index) items(l lookup) []caseItem {
+	if l.items != nil {
+		return l.items
 	}
-	return nil, false
+	return ix.tableItems(l.table, l.key)
 }
 
-func isSelector(n ast.Node) (*ast.Ident, string, bool) {
-	if sel, ok := n.(*ast.SelectorExpr); ok {
-		if ident, ok := sel.X.(*ast.Ident); ok {
-			return ident, sel.Sel.Name, true
-		}
+// lookup describes how to find a test case by its name.
+type lookup struct {
+	// table is the expression initializing the test cases, eg. []testcase{...}
+	table ast.Expr
+	// key is the field of a test case holding its name
+	key string
+	// match returns the matcher for the name being looked up
+	match func(value string) matcher
+	// items, if not nil, are the test cases to use instead of the ones
+	// found in table by key
+	items []caseItem
+	// err is the reason why no test case can be found
+	err error
+	// inexact is true if the test cases are matched by a heuristic
+	inexact bool
+	// call and arg are the call looking up the test case and its argument
+	// giving the name, as found at the caller line
+	call *ast.CallExpr
+	arg  ast.Expr
+}
+
+// MaxSearchDepth limits the depth of nested expressions followed while
+// searching for test cases, protecting against pathological inputs such as
+// generated sources. Beyond it the search stops with a diagnostic.
+var MaxSearchDepth = defaultMaxSearchDepth
+
+const defaultMaxSearchDepth = 32
+
+// ErrNotFound is returned when no test case has the name looked up.
+var ErrNotFound = errors.New("dataloc: test case not found")
+
+// ErrFieldNotFound is returned when the field holding the name of the test
+// cases is not found in their struct type.
+var ErrFieldNotFound = errors.New("dataloc: field not found")
+
+// lookupOf returns the lookup for arg, the expression passed to dataloc.L().
+// The lookup carries an error if its name field is missing from the struct
+// type of the test cases.
+func (ix *index) lookupOf(arg ast.Expr) (lookup, bool) {
+	l, ok := ix.lookupOfArg(arg)
+	if ok && l.items == nil && l.key != "" {
+		l.err = ix.checkField(l.table, l.key)
 	}
-	return nil, "", false
+	return l, ok
 }
 
-func findTestCaseItem(init ast.Expr, key, value string, objToTypeDecl map[*ast.Object]ast.Expr) ast.Node {
-	testcases, ok := init.(*ast.CompositeLit)
+// checkField confirms that the struct type of the elements of the slice table
+// has the field key.
+func (ix *index) checkField(table ast.Expr, key string) error {
+	lit, ok := table.(*ast.CompositeLit)
 	if !ok {
 		return nil
 	}
+	if _, ok := lit.Type.(*ast.ArrayType); !ok {
+		return nil
+	}
 
-	var testcaseType ast.Expr
-	if t, ok := testcases.Type.(*ast.ArrayType); ok {
-		testcaseType = t.Elt
-		if ident, ok := testcaseType.(*ast.Ident); ok {
-			testcaseType = objToTypeDecl[ident.Obj]
-			if testcaseType == nil {
-				logf("could not resolve type of %s", ident.Name)
-				return nil
-			}
-		}
-	} else if m, ok := testcases.Type.(*ast.MapType); ok {
-		testcaseType = m
-	} else {
-		// testcases should be an array eg.
-		//   testcases := []testcase{ ... }
-		// or a map eg.
-		//   testcases := map[string]testcase{ ... }
-		debugf("unexpected testcase type: %#v", testcases.Type)
+	elem := unstar(elemType(table))
+	typeName := types.ExprString(elem)
+	elem = uninstantiate(elem)
+	if ident, ok := elem.(*ast.Ident); ok {
+		elem = ix.objToTypeDecl[ident.Obj]
+	}
+	if _, ok := elem.(*ast.StructType); !ok {
+		return nil
+	}
+	if ix.hasUnresolvedEmbedding(elem, 0) {
+		// the field may be promoted from a type of another package,
+		// eg. url.URL, whose fields are unknown
 		return nil
 	}
+	if ix.fieldPath(elem, key, 0) == nil {
+		ix.logf("field %s not found in type %s", key, typeName)
+		return fmt.Errorf("%w: %s in type %s", ErrFieldNotFound, key, typeName)
+	}
+	return nil
+}
 
-	for _, testcase := range testcases.Elts {
-		if kv, ok := testcase.(*ast.KeyValueExpr); ok {
-			if basic, ok := kv.Key.(*ast.BasicLit); ok {
-				if isStringLiteral(basic, value) {
-					return kv
+func (ix *index) lookupOfArg(arg ast.Expr) (lookup, bool) {
+	// dataloc.L(string(caseName(testdata.name))) is dataloc.L(testdata.name)
+	arg = ix.unconvert(arg)
+
+	// for example:
+	//   testcases := []struct{}{...}
+	//   for _, testdata := range testcases {
+	//     dataloc.L(testdata.name)
+	//   }
+	// ident = testdata, key = name
+	if ident, key, ok := isSelector(arg); ok {
+		// expr = testcases
+		if expr, ok := ix.rangeExprForValue(ident); ok {
+			// testcasesExpr = []struct{}{...}
+			testcasesExpr := ix.tableExpr(expr)
+			return lookup{table: testcasesExpr, key: key, match: ix.stringMatcher}, true
+		}
+	} else if ident, method, ok := isMethodSelector(arg); ok {
+		// for _, testdata := range testcases {
+		//   dataloc.L(testdata.name())
+		// }
+		// where name() returns a field of testdata
+		if expr, ok := ix.rangeExprForValue(ident); ok {
+			testcasesExpr := ix.tableExpr(expr)
+			if key := ix.methodField(testcasesExpr, method); key != "" {
+				return lookup{table: testcasesExpr, key: key, match: ix.stringMatcher}, true
+			}
+		}
+	} else if call, key, ok := isCallSelector(arg); ok {
+		// byName := map[string]testcase{...}
+		// func lookup(key string) testcase { return byName[key] }
+		// ...
+		// dataloc.L(lookup(key).name)
+		if testcasesExpr, ok := ix.helperTable(call); ok {
+			return lookup{table: testcasesExpr, key: key, match: ix.stringMatcher, items: ix.mapValueItems(testcasesExpr, key)}, true
+		}
+		ix.logf("unsupported form of argument: %s, where the function must return an element of a table indexed by its argument", types.ExprString(arg))
+	} else if table, key, ok := isIndexSelector(arg); ok {
+		// for key := range testcases {
+		//   dataloc.L(testcases[key].name)
+		// }
+		// or "for i := range testcases" over a slice
+		if testcasesExpr := ix.tableExpr(table); testcasesExpr != nil {
+			return lookup{table: testcasesExpr, key: key, match: ix.stringMatcher, items: ix.mapValueItems(testcasesExpr, key)}, true
+		}
+	} else if call, ok := arg.(*ast.CallExpr); ok && len(call.Args) == 0 {
+		// for _, testdata := range testcases {
+		//   nameOf := testdata.nameFunc
+		//   dataloc.L(nameOf())
+		// }
+		// where nameFunc is given as "func() string { return ... }"
+		if fun, ok := call.Fun.(*ast.Ident); ok {
+			if ident, key, ok := isSelector(ix.objToVarInit[fun.Obj]); ok {
+				if expr, ok := ix.rangeExprForValue(ident); ok {
+					testcasesExpr := ix.tableExpr(expr)
+					return lookup{table: testcasesExpr, key: key, match: ix.funcResultMatcher}, true
 				}
 			}
 		}
-
-		testcase, ok := testcase.(*ast.CompositeLit)
+		ix.logf("unsupported form of argument: %s, which must be a call of a variable assigned a field of a test case", types.ExprString(arg))
+	} else if ident, ok := arg.(*ast.Ident); ok {
+		// for k, v := range testcases {
+		//   dataloc.L(k)
+		// }
+		if expr, ok := ix.objToRangeExprForKey[ident.Obj]; ok {
+			testcasesExpr := ix.tableExpr(expr)
+			return lookup{table: testcasesExpr, key: ident.Name, match: ix.keyMatcher}, true
+		}
+		// names := []string{"foo", ...}
+		// for _, name := range names {
+		//   dataloc.L(name)
+		// }
+		if expr, ok := ix.rangeExprForValue(ident); ok {
+			testcasesExpr := ix.tableExpr(expr)
+			if testcasesExpr == nil {
+				// for _, name := range []string{"foo", ...}
+				testcasesExpr = expr
+			}
+			if items := elementItems(testcasesExpr); items != nil {
+				return lookup{table: testcasesExpr, match: ix.stringMatcher, items: items}, true
+			}
+		}
+		// for _, testdata := range testcases {
+		//   name := testdata.name
+		//   dataloc.L(name)
+		// }
+		if init, ok := ix.objToVarInit[ident.Obj]; ok {
+			if _, ok := init.(*ast.Ident); !ok {
+				return ix.lookupOfArg(init)
+			}
+		}
+	} else if x, base, ok := isIntFormat(arg); ok {
+		match := func(value string) matcher {
+			return ix.intMatcher(value, base)
+		}
+		if ident, key, ok := isSelector(x); ok {
+			// for _, testdata := range testcases {
+			//   dataloc.L(strconv.Itoa(testdata.id))
+			// }
+			if expr, ok := ix.rangeExprForValue(ident); ok {
+				testcasesExpr := ix.tableExpr(expr)
+				return lookup{table: testcasesExpr, key: key, match: match}, true
+			}
+		} else if ident, ok := x.(*ast.Ident); ok {
+			// for id, testdata := range testcases {
+			//   dataloc.L(strconv.Itoa(id))
+			// }
+			if expr, ok := ix.objToRangeExprForKey[ident.Obj]; ok {
+				testcasesExpr := ix.tableExpr(expr)
+				return lookup{table: testcasesExpr, key: ident.Name, match: match}, true
+			}
+		}
+	} else if outer, sep, inner, ok := isJoinedKeys(arg); ok {
+		// for group, cases := range testcases {
+		//   for name, testdata := range cases {
+		//     dataloc.L(group + "/" + name)
+		//   }
+		// }
+		outerExpr, ok := ix.objToRangeExprForKey[outer.Obj]
 		if !ok {
-			// testcase should be a struct literal eg.
-			//   { name: "foo", ... }
-			// or
-			//   { "foo", ... }
-			continue
+			return lookup{}, false
 		}
-
-		for i, field := range testcase.Elts {
-			if kv, ok := field.(*ast.KeyValueExpr); ok {
-				// { <key>: <value>, ... }
-				if ident, ok := kv.Key.(*ast.Ident); ok {
-					if ident.Name == key {
-						if isStringLiteral(kv.Value, value) {
-							return testcase
-						}
-					}
+		innerExpr, ok := ix.objToRangeExprForKey[inner.Obj].(*ast.Ident)
+		if !ok {
+			return lookup{}, false
+		}
+		if expr, ok := ix.rangeExprForValue(innerExpr); !ok || expr != outerExpr {
+			return lookup{}, false
+		}
+		testcasesExpr := ix.tableExpr(outerExpr)
+		return lookup{table: testcasesExpr, match: ix.stringMatcher, items: joinedKeyItems(testcasesExpr, sep)}, true
+	} else if ident, key, prefix, suffix, ok := ix.isAffixedSelector(arg); ok {
+		// for _, testdata := range testcases {
+		//   dataloc.L(testdata.name + "_variant")
+		// }
+		if expr, ok := ix.rangeExprForValue(ident); ok {
+			testcasesExpr := ix.tableExpr(expr)
+			match := func(value string) matcher {
+				if len(value) < len(prefix)+len(suffix) || !strings.HasPrefix(value, prefix) || !strings.HasSuffix(value, suffix) {
+					return func(caseItem) bool { return false }
+				}
+				return ix.stringMatcher(value[len(prefix) : len(value)-len(suffix)])
+			}
+			return lookup{table: testcasesExpr, key: key, match: match}, true
+		}
+	} else if ident, key, names, ok := ix.isNameMapIndex(arg); ok {
+		// names := map[kind]string{kindFoo: "foo", ...}
+		// for _, testdata := range testcases {
+		//   dataloc.L(names[testdata.kind])
+		// }
+		if expr, ok := ix.rangeExprForValue(ident); ok {
+			testcasesExpr := ix.tableExpr(expr)
+			match := func(value string) matcher {
+				return func(item caseItem) bool {
+					c, ok := item.name.(*ast.Ident)
+					return ok && names[c.Name] == value
 				}
-			} else if basic, ok := field.(*ast.BasicLit); ok {
-				// { <value>, ...}
-				if findStructFieldIndex(testcaseType, key) == i {
-					if isStringLiteral(basic, value) {
-						return testcase
+			}
+			return lookup{table: testcasesExpr, key: key, match: match}, true
+		}
+	} else if ident, key, format, ok := ix.isIndexFormat(arg); ok {
+		// for i, testdata := range testcases {
+		//   dataloc.L(fmt.Sprintf("%s#%d", testdata.name, i))
+		// }
+		expr, _ := ix.rangeExprForValue(ident)
+		testcasesExpr := ix.tableExpr(expr)
+		match := func(value string) matcher {
+			return func(item caseItem) bool {
+				name, ok := ix.stringValue(item.name)
+				return ok && format(name, item.index) == value
+			}
+		}
+		return lookup{table: testcasesExpr, key: key, match: match, inexact: true}, true
+	} else if ident, group, sep, key, ok := ix.isEnumJoin(arg); ok {
+		// for _, testdata := range testcases {
+		//   dataloc.L(testdata.group.String() + ":" + testdata.name)
+		// }
+		if expr, ok := ix.rangeExprForValue(ident); ok {
+			testcasesExpr := ix.tableExpr(expr)
+			groups := map[int]ast.Expr{}
+			for _, item := range ix.tableItems(testcasesExpr, group) {
+				groups[item.index] = item.name
+			}
+			match := func(value string) matcher {
+				return func(item caseItem) bool {
+					name, ok := ix.stringValue(item.name)
+					if !ok || !strings.HasSuffix(value, sep+name) {
+						return false
 					}
+					return enumStringMatches(constName(groups[item.index]), value[:len(value)-len(sep+name)])
 				}
 			}
+			return lookup{table: testcasesExpr, key: key, match: match, inexact: true}, true
 		}
 	}
-
-	return nil
+	return lookup{}, false
 }
 
-func isStringLiteral(n ast.Expr, s string) bool {
-	lit, ok := n.(*ast.BasicLit)
+// isEnumJoin matches "x.group.String() + sep + x.key" where sep is a string
+// constant.
+func (ix *index) isEnumJoin(n ast.Node) (*ast.Ident, string, string, string, bool) {
+	bin, ok := n.(*ast.BinaryExpr)
+	if !ok || bin.Op != token.ADD {
+		return nil, "", "", "", false
+	}
+	ident, key, ok := isSelector(bin.Y)
 	if !ok {
-		return false
+		return nil, "", "", "", false
 	}
-	if lit.Kind != token.STRING {
-		return false
+	left, ok := bin.X.(*ast.BinaryExpr)
+	if !ok || left.Op != token.ADD {
+		return nil, "", "", "", false
 	}
-	return lit.Value == strconv.Quote(s)
-}
-
-func findStructFieldIndex(t ast.Expr, name string) int {
-	typ, ok := t.(*ast.StructType)
+	sep, ok := ix.stringValue(left.Y)
 	if !ok {
-		return -1
+		return nil, "", "", "", false
+	}
+	call, ok := left.X.(*ast.CallExpr)
+	if !ok || len(call.Args) != 0 {
+		return nil, "", "", "", false
+	}
+	fun, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || fun.Sel.Name != "String" {
+		return nil, "", "", "", false
+	}
+	x, group, ok := isSelector(fun.X)
+	if !ok || x.Obj == nil || x.Obj != ident.Obj {
+		return nil, "", "", "", false
+	}
+	return ident, group, sep, key, true
+}
+
+// constName returns the name of the constant expr, eg. "groupAdmin" for
+// groupAdmin or pkg.groupAdmin, or "" if expr is not a name.
+func constName(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return expr.Name
+	case *ast.SelectorExpr:
+		return expr.Sel.Name
+	}
+	return ""
+}
+
+// enumStringMatches reports whether s may be the String() of the constant
+// named name. This is a heuristic as the method is not evaluated: s must be
+// the name, or its last words, eg. "admin" or "Admin" for groupAdmin,
+// ignoring case.
+func enumStringMatches(name, s string) bool {
+	i := len(name) - len(s)
+	if s == "" || i < 0 || !strings.EqualFold(name[i:], s) {
+		return false
+	}
+	return i == 0 || name[i-1] == '_' || unicode.IsUpper(rune(name[i]))
+}
+
+// isJoinedKeys matches "outer + sep + inner" where outer and inner are
+// identifiers and sep is a string literal.
+func isJoinedKeys(n ast.Node) (*ast.Ident, string, *ast.Ident, bool) {
+	bin, ok := n.(*ast.BinaryExpr)
+	if !ok || bin.Op != token.ADD {
+		return nil, "", nil, false
+	}
+	inner, ok := bin.Y.(*ast.Ident)
+	if !ok {
+		return nil, "", nil, false
+	}
+	left, ok := bin.X.(*ast.BinaryExpr)
+	if !ok || left.Op != token.ADD {
+		return nil, "", nil, false
+	}
+	outer, ok := left.X.(*ast.Ident)
+	if !ok {
+		return nil, "", nil, false
+	}
+	sep, ok := stringValue(left.Y)
+	if !ok {
+		return nil, "", nil, false
+	}
+	return outer, sep, inner, true
+}
+
+// joinedKeyItems returns the entries of the nested maps in table, a map
+// of maps, named by their keys joined with the outer keys by sep.
+func joinedKeyItems(table ast.Expr, sep string) []caseItem {
+	lit, ok := table.(*ast.CompositeLit)
+	if !ok {
+		return nil
+	}
+
+	var items []caseItem
+	for _, elt := range lit.Elts {
+		outer, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		outerKey, ok := stringValue(outer.Key)
+		if !ok {
+			continue
+		}
+		cases, ok := outer.Value.(*ast.CompositeLit)
+		if !ok {
+			continue
+		}
+		for _, elt := range cases.Elts {
+			inner, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				continue
+			}
+			innerKey, ok := stringValue(inner.Key)
+			if !ok {
+				continue
+			}
+			// a synthesized literal of the joined name, placed at the inner key
+			name := &ast.BasicLit{
+				ValuePos: inner.Key.Pos(),
+				Kind:     token.STRING,
+				Value:    strconv.Quote(outerKey + sep + innerKey),
+			}
+			items = append(items, caseItem{inner, name, len(items)})
+		}
+	}
+	return items
+}
+
+// elementItems returns the elements of table, a literal of a slice of
+// strings, each named by itself, or nil if table is not such a literal.
+func elementItems(table ast.Expr) []caseItem {
+	lit, ok := table.(*ast.CompositeLit)
+	if !ok {
+		return nil
+	}
+	t, ok := lit.Type.(*ast.ArrayType)
+	if !ok {
+		return nil
+	}
+	if ident, ok := t.Elt.(*ast.Ident); !ok || ident.Name != "string" {
+		return nil
+	}
+
+	items := []caseItem{}
+	for index, elt := range lit.Elts {
+		items = append(items, caseItem{elt, elt, index})
+	}
+	return items
+}
+
+// isAffixedSelector matches "prefix + x.key + suffix" where prefix and
+// suffix are string literals, either of which may be omitted.
+func (ix *index) isAffixedSelector(n ast.Node) (*ast.Ident, string, string, string, bool) {
+	var operands []ast.Expr
+	var flatten func(expr ast.Expr, depth int) bool
+	flatten = func(expr ast.Expr, depth int) bool {
+		if depth > ix.maxSearchDepth() {
+			ix.logf("maximum search depth %d exceeded at %s", ix.maxSearchDepth(), types.ExprString(expr))
+			return false
+		}
+		if bin, ok := expr.(*ast.BinaryExpr); ok {
+			return bin.Op == token.ADD && flatten(bin.X, depth+1) && flatten(bin.Y, depth+1)
+		}
+		operands = append(operands, expr)
+		return true
+	}
+	if _, ok := n.(*ast.BinaryExpr); !ok || !flatten(n.(ast.Expr), 0) {
+		return nil, "", "", "", false
+	}
+
+	var (
+		ident          *ast.Ident
+		key            string
+		prefix, suffix strings.Builder
+	)
+	for _, operand := range operands {
+		if x, sel, ok := isSelector(operand); ok && ident == nil {
+			ident, key = x, sel
+		} else if s, ok := stringValue(operand); ok {
+			if ident == nil {
+				prefix.WriteString(s)
+			} else {
+				suffix.WriteString(s)
+			}
+		} else {
+			return nil, "", "", "", false
+		}
+	}
+	if ident == nil {
+		return nil, "", "", "", false
+	}
+	return ident, key, prefix.String(), suffix.String(), true
+}
+
+// isNameMapIndex matches "names[x.key]" where names is a map literal from
+// constants to string literals, which is returned keyed by the constant names.
+func (ix *index) isNameMapIndex(n ast.Node) (*ast.Ident, string, map[string]string, bool) {
+	index, ok := n.(*ast.IndexExpr)
+	if !ok {
+		return nil, "", nil, false
+	}
+	ident, key, ok := isSelector(index.Index)
+	if !ok {
+		return nil, "", nil, false
+	}
+	namesIdent, ok := index.X.(*ast.Ident)
+	if !ok {
+		return nil, "", nil, false
+	}
+	lit, ok := ix.objToVarInit[namesIdent.Obj].(*ast.CompositeLit)
+	if !ok {
+		return nil, "", nil, false
+	}
+	if _, ok := lit.Type.(*ast.MapType); !ok {
+		return nil, "", nil, false
+	}
+
+	names := map[string]string{}
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		c, ok := kv.Key.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		if s, ok := stringValue(kv.Value); ok {
+			names[c.Name] = s
+		}
+	}
+	return ident, key, names, true
+}
+
+// isIndexFormat matches "fmt.Sprintf(format, x.key, i)" where x and i are the
+// value and the key of the same range statement, in any order.
+// The returned function reproduces the formatting from the name and the index
+// of a test case. This is a heuristic as it assumes that i is the position of
+// the test case in the table literal.
+func (ix *index) isIndexFormat(n ast.Node) (*ast.Ident, string, func(string, int) string, bool) {
+	call, ok := n.(*ast.CallExpr)
+	if !ok || len(call.Args) != 3 {
+		return nil, "", nil, false
+	}
+	if pkg, fun, ok := isSelector(call.Fun); !ok || pkg.Name != "fmt" || fun != "Sprintf" {
+		return nil, "", nil, false
+	}
+	format, ok := stringValue(call.Args[0])
+	if !ok {
+		return nil, "", nil, false
+	}
+
+	var (
+		value, index *ast.Ident
+		key          string
+		indexFirst   bool
+	)
+	for i, arg := range call.Args[1:] {
+		if ident, sel, ok := isSelector(arg); ok {
+			value, key = ident, sel
+		} else if ident, ok := arg.(*ast.Ident); ok {
+			index, indexFirst = ident, i == 0
+		}
+	}
+	if value == nil || index == nil {
+		return nil, "", nil, false
+	}
+
+	valueExpr, ok := ix.rangeExprForValue(value)
+	if !ok || ix.objToRangeExprForKey[index.Obj] != valueExpr {
+		return nil, "", nil, false
+	}
+
+	return value, key, func(name string, i int) string {
+		if indexFirst {
+			return fmt.Sprintf(format, i, name)
+		}
+		return fmt.Sprintf(format, name, i)
+	}, true
+}
+
+// methodField returns the name of the field returned by the method of the
+// element type of the table, eg. "label" for
+//
+//	func (c *testcase) name() string { return c.label }
+func (ix *index) methodField(table ast.Expr, method string) string {
+	typeName := ""
+	if ident, ok := unstar(elemType(table)).(*ast.Ident); ok {
+		typeName = ident.Name
+	}
+
+	for _, fn := range ix.methods[method] {
+		recv := fn.Recv.List[0]
+		if ident, ok := unstar(recv.Type).(*ast.Ident); !ok || typeName != "" && ident.Name != typeName {
+			continue
+		}
+		if len(recv.Names) != 1 || fn.Body == nil || len(fn.Body.List) != 1 {
+			continue
+		}
+		ret, ok := fn.Body.List[0].(*ast.ReturnStmt)
+		if !ok || len(ret.Results) != 1 {
+			continue
+		}
+		if ident, field, ok := isSelector(ret.Results[0]); ok && ident.Name == recv.Names[0].Name {
+			return field
+		}
+	}
+	return ""
+}
+
+// elemType returns the element type of the slice or map literal table.
+func elemType(table ast.Expr) ast.Expr {
+	lit, ok := table.(*ast.CompositeLit)
+	if !ok {
+		return nil
+	}
+	switch t := lit.Type.(type) {
+	case *ast.ArrayType:
+		return t.Elt
+	case *ast.MapType:
+		return t.Value
+	}
+	return nil
+}
+
+func unstar(expr ast.Expr) ast.Expr {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		return star.X
+	}
+	return expr
+}
+
+// lookupsIn returns the lookups of the dataloc.L() calls inside node,
+// one for each distinct table and name field, where pkg is the name the
+// file of node imports this package as.
+func (ix *index) lookupsIn(node ast.Node, pkg string) []lookup {
+	type tableKey struct {
+		table ast.Expr
+		key   string
+	}
+	seen := map[tableKey]bool{}
+
+	var lookups []lookup
+	ast.Inspect(node, func(n ast.Node) bool {
+		_, arg, ok := isNameFuncCall(n, pkg)
+		if !ok {
+			return true
+		}
+		l, ok := ix.lookupOf(arg)
+		if !ok || l.table == nil || seen[tableKey{l.table, l.key}] {
+			return true
+		}
+		seen[tableKey{l.table, l.key}] = true
+		lookups = append(lookups, l)
+		return true
+	})
+	return lookups
+}
+
+// enclosingFunc returns the top-level function declaration in f which
+// contains line.
+func enclosingFunc(fset *token.FileSet, f *ast.File, line int) *ast.FuncDecl {
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		if fset.Position(fn.Pos()).Line <= line && line <= fset.Position(fn.End()).Line {
+			return fn
+		}
+	}
+	return nil
+}
+
+// rangeExprForValue returns the range expression that declares ident as its
+// value, following copies of the value such as "testcase := testcase"
+// or "tc := testdata".
+func (ix *index) rangeExprForValue(ident *ast.Ident) (ast.Expr, bool) {
+	// bound the number of copies followed in case of a cycle
+	for i := 0; i <= ix.maxSearchDepth(); i++ {
+		if expr, ok := ix.objToRangeExprForValue[ident.Obj]; ok {
+			return expr, true
+		}
+		// for testcase := range seq, where seq is an iterator
+		if expr, ok := ix.objToRangeExprForKey[ident.Obj]; ok && ix.iteratorTable(expr, 0) != nil {
+			return expr, true
+		}
+		next, ok := ix.objToVarInit[ident.Obj].(*ast.Ident)
+		if !ok || next.Obj == nil || next.Obj == ident.Obj {
+			break
+		}
+		ident = next
+	}
+	return nil, false
+}
+
+// tableExpr resolves the expression being ranged over to the expression
+// that initializes the test cases, typically a composite literal.
+func (ix *index) tableExpr(expr ast.Expr) ast.Expr {
+	return ix.tableExprSeen(expr, map[*ast.Object]bool{})
+}
+
+// tableExprSeen is tableExpr following the variables aliasing others, eg.
+// "var testcases = base", of which seen are those already followed.
+func (ix *index) tableExprSeen(expr ast.Expr, seen map[*ast.Object]bool) ast.Expr {
+	if table := ix.iteratorTable(expr, 0); table != nil {
+		// for _, testcase := range seq, where seq is an iterator over a table
+		return table
+	}
+
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		// for _, testcase := range testcases
+		if expr.Obj != nil {
+			if seen[expr.Obj] {
+				ix.logf("cyclic initialization of %s", expr.Name)
+				return nil
+			}
+			seen[expr.Obj] = true
+		}
+		init := ix.objToVarInit[expr.Obj]
+		typ := ix.objToVarType[expr.Obj]
+		if alias, ok := init.(*ast.Ident); ok && alias.Obj != nil && alias.Obj.Kind == ast.Var {
+			// var testcases = base
+			init = ix.tableExprSeen(alias, seen)
+		}
+		if call, ok := init.(*ast.CallExpr); ok {
+			if fun, ok := call.Fun.(*ast.Ident); ok && fun.Name == "make" && fun.Obj == nil && len(call.Args) > 0 {
+				// testcases := make([]testcase, n)
+				typ = call.Args[0]
+			} else {
+				// testcases := cases[int]()
+				// where "func cases[T any]() []testcase[T] { return []testcase[T]{...} }"
+				init = ix.returnedLiteral(call)
+			}
+		}
+		appends := ix.objToAppends[expr.Obj]
+		assigns := ix.objToIndexAssigns[expr.Obj]
+		if len(appends) == 0 && len(assigns) == 0 {
+			return init
+		}
+		// var testcases []testcase
+		// func init() { testcases = append(testcases, testcase{...}) }
+		// is taken as if it were "testcases := []testcase{...}"
+		table := &ast.CompositeLit{Type: typ}
+		if lit, ok := init.(*ast.CompositeLit); ok {
+			table.Type = lit.Type
+			table.Elts = append(table.Elts, lit.Elts...)
+		}
+		table.Elts = append(table.Elts, appends...)
+		// and so is "testcases[0] = testcase{...}", or for a map
+		// "testcases["foo"] = testcase{...}" as if it were an entry
+		_, isMap := table.Type.(*ast.MapType)
+		for _, kv := range assigns {
+			if isMap {
+				table.Elts = append(table.Elts, kv)
+			} else {
+				table.Elts = append(table.Elts, kv.Value)
+			}
+		}
+		return table
+	case *ast.CallExpr:
+		// for _, testcase := range cases()
+		// where "func cases() []testcase { return []testcase{...} }"
+		return ix.returnedLiteral(expr)
+	case *ast.UnaryExpr:
+		// for _, testcase := range &testcases
+		// where testcases is an array, eg. [...]testcase{...}
+		if expr.Op == token.AND {
+			return ix.tableExprSeen(expr.X, seen)
+		}
+	case *ast.SelectorExpr:
+		// for _, testcase := range config.cases
+		// where config := configType{cases: ...}
+		if ident, ok := expr.X.(*ast.Ident); ok {
+			if lit, ok := ix.objToVarInit[ident.Obj].(*ast.CompositeLit); ok {
+				return ix.fieldValue(lit, expr.Sel.Name)
+			}
+		}
+		// for _, testcase := range s.cases
+		// where "s.cases = []testcase{...}", eg. in SetupTest
+		for _, value := range ix.fieldAssigns[expr.Sel.Name] {
+			if _, ok := value.(*ast.CompositeLit); ok {
+				return value
+			}
+			if table := ix.tableExprSeen(value, seen); table != nil {
+				return table
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+// iteratorTable returns the table over which expr, a range-over-func
+// iterator, ranges to yield the test cases, eg.
+//
+//	func(yield func(testcase) bool) {
+//		for _, testcase := range testcases {
+//			if !yield(testcase) {
+//				return
+//			}
+//		}
+//	}
+//
+// The iterator may be such a function literal, a variable or a function
+// declared in the file, or a call to a function of the file returning it.
+// Nil is returned if expr is not an iterator.
+func (ix *index) iteratorTable(expr ast.Expr, depth int) ast.Expr {
+	if depth > ix.maxSearchDepth() {
+		ix.logf("maximum search depth %d exceeded at %s", ix.maxSearchDepth(), types.ExprString(expr))
+		return nil
+	}
+
+	var fn *ast.FuncType
+	var body *ast.BlockStmt
+	switch expr := expr.(type) {
+	case *ast.FuncLit:
+		fn, body = expr.Type, expr.Body
+	case *ast.Ident:
+		if expr.Obj == nil {
+			return nil
+		}
+		if decl, ok := expr.Obj.Decl.(*ast.FuncDecl); ok {
+			fn, body = decl.Type, decl.Body
+		} else if init := ix.objToVarInit[expr.Obj]; init != nil {
+			switch init.(type) {
+			case *ast.FuncLit, *ast.CallExpr:
+				return ix.iteratorTable(init, depth+1)
+			}
+			return nil
+		}
+	case *ast.CallExpr:
+		ident, ok := uninstantiate(expr.Fun).(*ast.Ident)
+		if !ok || ident.Obj == nil {
+			return nil
+		}
+		decl, ok := ident.Obj.Decl.(*ast.FuncDecl)
+		if !ok || decl.Body == nil {
+			return nil
+		}
+		for _, stmt := range decl.Body.List {
+			if ret, ok := stmt.(*ast.ReturnStmt); ok && len(ret.Results) == 1 {
+				if lit, ok := ret.Results[0].(*ast.FuncLit); ok {
+					return ix.iteratorTable(lit, depth+1)
+				}
+			}
+		}
+		return nil
+	}
+	if body == nil || fn.Params == nil || len(fn.Params.List) != 1 || len(fn.Params.List[0].Names) != 1 {
+		return nil
+	}
+	if _, ok := fn.Params.List[0].Type.(*ast.FuncType); !ok {
+		return nil
+	}
+	yield := fn.Params.List[0].Names[0].Obj
+
+	// the loop over the table, yielding its key or value
+	var table ast.Expr
+	ast.Inspect(body, func(n ast.Node) bool {
+		rangeStmt, ok := n.(*ast.RangeStmt)
+		if !ok || table != nil {
+			return table == nil
+		}
+		ast.Inspect(rangeStmt.Body, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || table != nil {
+				return table == nil
+			}
+			if fun, ok := call.Fun.(*ast.Ident); !ok || fun.Obj != yield {
+				return true
+			}
+			for _, arg := range call.Args {
+				if ident, ok := arg.(*ast.Ident); ok && ident.Obj != nil && (isIdentOf(rangeStmt.Key, ident.Obj) || isIdentOf(rangeStmt.Value, ident.Obj)) {
+					table = ix.tableExpr(rangeStmt.X)
+				}
+			}
+			return true
+		})
+		return true
+	})
+	return table
+}
+
+// isIdentOf reports whether expr is an identifier of obj.
+func isIdentOf(expr ast.Expr, obj *ast.Object) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Obj == obj
+}
+
+// returnedLiteral returns the composite literal returned by call, a call to
+// a function declared in the file, possibly generic, or nil if the function
+// does not simply return a literal.
+func (ix *index) returnedLiteral(call *ast.CallExpr) ast.Expr {
+	fun := uninstantiate(call.Fun)
+	ident, ok := fun.(*ast.Ident)
+	if !ok || ident.Obj == nil || ident.Obj.Kind != ast.Fun {
+		return nil
+	}
+	decl, ok := ident.Obj.Decl.(*ast.FuncDecl)
+	if !ok || decl.Body == nil {
+		return nil
+	}
+
+	for _, stmt := range decl.Body.List {
+		if ret, ok := stmt.(*ast.ReturnStmt); ok && len(ret.Results) == 1 {
+			if lit, ok := ret.Results[0].(*ast.CompositeLit); ok {
+				return lit
+			}
+		}
+	}
+	ix.logf("unsupported function %s, which must return a literal of the test cases", ident.Name)
+	return nil
+}
+
+// uninstantiate returns the generic function or type instantiated by expr,
+// eg. "cases" for "cases[int]", or expr itself.
+func uninstantiate(expr ast.Expr) ast.Expr {
+	switch e := expr.(type) {
+	case *ast.IndexExpr:
+		return e.X
+	case *ast.IndexListExpr:
+		return e.X
+	}
+	return expr
+}
+
+// fieldValue returns the value given to the field named name in the struct
+// literal lit, either keyed or positional, including a field promoted from an
+// embedded struct.
+func (ix *index) fieldValue(lit *ast.CompositeLit, name string) ast.Expr {
+	var typ ast.Expr = lit.Type
+	if ident, ok := typ.(*ast.Ident); ok {
+		typ = ix.objToTypeDecl[ident.Obj]
+	}
+
+	for _, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			if ident, ok := kv.Key.(*ast.Ident); ok && ix.fieldNameEqual(ident.Name, name) {
+				return kv.Value
+			}
+		} else {
+			return positionalField(lit, ix.fieldPath(typ, name, 0))
+		}
+	}
+
+	// { base: base{ name: ... }, ... }
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		value := kv.Value
+		if unary, ok := value.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+			value = unary.X
+		}
+		embedded, ok := value.(*ast.CompositeLit)
+		if !ok {
+			continue
+		}
+		key, ok := kv.Key.(*ast.Ident)
+		if !ok || key.Name != embeddedName(embedded.Type) {
+			continue
+		}
+		if v := ix.fieldValue(embedded, name); v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// embeddedName returns the name of the field embedding the type t, eg.
+// "URL" for url.URL or "base" for *base[T].
+func embeddedName(t ast.Expr) string {
+	switch t := uninstantiate(unstar(t)).(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	}
+	return ""
+}
+
+func isSelector(n ast.Node) (*ast.Ident, string, bool) {
+	if sel, ok := n.(*ast.SelectorExpr); ok {
+		if ident, ok := sel.X.(*ast.Ident); ok {
+			return ident, sel.Sel.Name, true
+		}
+	}
+	return nil, "", false
+}
+
+// unconvert strips the conversions such as "int64(x)" or
+// "string(caseName(x))" off expr.
+func (ix *index) unconvert(expr ast.Expr) ast.Expr {
+	// bound the number of conversions stripped
+	for i := 0; i <= ix.maxSearchDepth(); i++ {
+		call, ok := expr.(*ast.CallExpr)
+		if !ok || len(call.Args) != 1 || !ix.isConversion(call.Fun) {
+			break
+		}
+		expr = call.Args[0]
+	}
+	return expr
+}
+
+// isConversion reports whether fun, the function of a call, is a type.
+// An identifier not resolved in the file is looked up in the declarations
+// of the package, and if not found there, taken as a type unless it is a
+// builtin function such as len.
+func (ix *index) isConversion(fun ast.Expr) bool {
+	ident, ok := fun.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	if ident.Obj != nil {
+		return ident.Obj.Kind == ast.Typ
+	}
+	if kind, ok := ix.decls[ident.Name]; ok {
+		return kind == ast.Typ
+	}
+	_, builtin := types.Universe.Lookup(ident.Name).(*types.Builtin)
+	return !builtin
+}
+
+// isCallSelector matches "f(...).key".
+func isCallSelector(n ast.Node) (*ast.CallExpr, string, bool) {
+	if sel, ok := n.(*ast.SelectorExpr); ok {
+		if call, ok := sel.X.(*ast.CallExpr); ok {
+			return call, sel.Sel.Name, true
+		}
+	}
+	return nil, "", false
+}
+
+// isIndexSelector matches "table[i].key".
+func isIndexSelector(n ast.Node) (ast.Expr, string, bool) {
+	if sel, ok := n.(*ast.SelectorExpr); ok {
+		if index, ok := sel.X.(*ast.IndexExpr); ok {
+			return index.X, sel.Sel.Name, true
+		}
+	}
+	return nil, "", false
+}
+
+// helperTable returns the table of which call, a call to a function declared
+// in the file, returns an element, eg.
+//
+//	func lookup(key string) testcase {
+//		return testcases[key]
+//	}
+//
+// The element may also be returned through a variable, eg.
+// "tc, ok := testcases[key]; ...; return tc", and the table may be passed as
+// an argument, eg.
+//
+//	func pick(cases []testcase) testcase {
+//		return cases[0]
+//	}
+func (ix *index) helperTable(call *ast.CallExpr) (ast.Expr, bool) {
+	fun, ok := call.Fun.(*ast.Ident)
+	if !ok || fun.Obj == nil || fun.Obj.Kind != ast.Fun {
+		return nil, false
+	}
+	decl, ok := fun.Obj.Decl.(*ast.FuncDecl)
+	if !ok || decl.Body == nil {
+		return nil, false
+	}
+
+	var table ast.Expr
+	ast.Inspect(decl.Body, func(n ast.Node) bool {
+		ret, ok := n.(*ast.ReturnStmt)
+		if table != nil || !ok || len(ret.Results) == 0 {
+			return table == nil
+		}
+		result := ret.Results[0]
+		if ident, ok := result.(*ast.Ident); ok {
+			result = ix.objToVarInit[ident.Obj]
+		}
+		if index, ok := result.(*ast.IndexExpr); ok {
+			x := index.X
+			if arg := paramArg(decl, call, x); arg != nil {
+				x = arg
+			}
+			table = ix.tableExpr(x)
+		}
+		return false
+	})
+	return table, table != nil
+}
+
+// paramArg returns the argument of call passed as expr, a parameter of decl,
+// the function called, or nil if expr is not a parameter.
+func paramArg(decl *ast.FuncDecl, call *ast.CallExpr, expr ast.Expr) ast.Expr {
+	ident, ok := expr.(*ast.Ident)
+	if !ok || ident.Obj == nil {
+		return nil
+	}
+	var i int
+	for _, field := range decl.Type.Params.List {
+		for _, name := range field.Names {
+			if name.Obj == ident.Obj {
+				if i < len(call.Args) {
+					return call.Args[i]
+				}
+				return nil
+			}
+			i++
+		}
+	}
+	return nil
+}
+
+// mapValueItems returns the entries of table, a map literal, named by the
+// field key of their values, or nil if table is not a map.
+func (ix *index) mapValueItems(table ast.Expr, key string) []caseItem {
+	lit, ok := table.(*ast.CompositeLit)
+	if !ok {
+		return nil
+	}
+	m, ok := lit.Type.(*ast.MapType)
+	if !ok {
+		return nil
+	}
+
+	items := []caseItem{}
+	for index, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		value, ok := kv.Value.(*ast.CompositeLit)
+		if !ok {
+			continue
+		}
+		if value.Type == nil {
+			// the type of a value may be elided, eg. "foo": {name: "foo"}
+			value = &ast.CompositeLit{Type: m.Value, Elts: value.Elts}
+		}
+		if name := ix.fieldValue(value, key); name != nil {
+			items = append(items, caseItem{kv, name, index})
+		}
+	}
+	return items
+}
+
+// isAppendTo matches "append(ident, ...)".
+func isAppendTo(expr ast.Expr, ident *ast.Ident) bool {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok || len(call.Args) == 0 || call.Ellipsis.IsValid() {
+		return false
+	}
+	if fun, ok := call.Fun.(*ast.Ident); !ok || fun.Name != "append" {
+		return false
+	}
+	arg, ok := call.Args[0].(*ast.Ident)
+	return ok && arg.Obj != nil && arg.Obj == ident.Obj
+}
+
+// isMethodSelector matches "x.m()", a method call without arguments.
+func isMethodSelector(n ast.Node) (*ast.Ident, string, bool) {
+	if call, ok := n.(*ast.CallExpr); ok && len(call.Args) == 0 {
+		return isSelector(call.Fun)
+	}
+	return nil, "", false
+}
+
+// A matcher reports whether the test case item has the name being looked up.
+type matcher func(item caseItem) bool
+
+// NameTransform, if set, normalizes both the names in the tables and the
+// name looked up before they are compared, eg. to lower case them.
+var NameTransform func(string) string
+
+func (ix *index) stringMatcher(value string) matcher {
+	transform := ix.nameTransform()
+	if transform == nil {
+		return func(item caseItem) bool {
+			// the literal is unquoted rather than value quoted, which
+			// would never match a raw string such as `GET /users/{id}`
+			name, ok := ix.stringValue(item.name)
+			return ok && name == value
+		}
+	}
+
+	value = transform(value)
+	return func(item caseItem) bool {
+		name, ok := ix.stringValue(item.name)
+		return ok && transform(name) == value
+	}
+}
+
+// funcResultMatcher matches func literals returning the name, eg.
+//
+//	func() string { return "foo" }
+func (ix *index) funcResultMatcher(value string) matcher {
+	match := ix.stringMatcher(value)
+	return func(item caseItem) bool {
+		fn, ok := item.name.(*ast.FuncLit)
+		if !ok || len(fn.Body.List) != 1 {
+			return false
+		}
+		ret, ok := fn.Body.List[0].(*ast.ReturnStmt)
+		if !ok || len(ret.Results) != 1 {
+			return false
+		}
+		item.name = ret.Results[0]
+		return match(item)
+	}
+}
+
+// intMatcher matches integer literals and constants which format as value
+// in the given base.
+func (ix *index) intMatcher(value string, base int) matcher {
+	return func(item caseItem) bool {
+		n, ok := ix.intValue(item.name)
+		return ok && strconv.FormatInt(n, base) == value
+	}
+}
+
+// keyMatcher matches the keys of a map, either strings which are value or,
+// as passed to LInt, integers which format as value in base 10.
+func (ix *index) keyMatcher(value string) matcher {
+	matchString, matchInt := ix.stringMatcher(value), ix.intMatcher(value, 10)
+	return func(item caseItem) bool {
+		return matchString(item) || matchInt(item)
+	}
+}
+
+// intValue returns the value of expr if it is an integer literal or constant.
+func (ix *index) intValue(expr ast.Expr) (int64, bool) {
+	if ident, ok := expr.(*ast.Ident); ok {
+		n, ok := ix.objToConstInt[ident.Obj]
+		return n, ok
+	}
+	return ix.evalInt(expr, -1)
+}
+
+// evalInt evaluates expr, a constant integer expression in a const
+// declaration where iota is given. A negative iota is not available.
+func (ix *index) evalInt(expr ast.Expr, iota int64) (int64, bool) {
+	return ix.evalIntDepth(expr, iota, 0)
+}
+
+func (ix *index) evalIntDepth(expr ast.Expr, iota int64, depth int) (int64, bool) {
+	if depth > ix.maxSearchDepth() {
+		ix.logf("maximum search depth %d exceeded at %s", ix.maxSearchDepth(), types.ExprString(expr))
+		return 0, false
+	}
+
+	switch expr := expr.(type) {
+	case *ast.BasicLit:
+		if expr.Kind != token.INT {
+			return 0, false
+		}
+		n, err := strconv.ParseInt(expr.Value, 0, 64)
+		return n, err == nil
+	case *ast.Ident:
+		if expr.Name == "iota" && expr.Obj == nil {
+			return iota, iota >= 0
+		}
+		n, ok := ix.objToConstInt[expr.Obj]
+		return n, ok
+	case *ast.ParenExpr:
+		return ix.evalIntDepth(expr.X, iota, depth+1)
+	case *ast.CallExpr:
+		// conversion eg. kind(iota)
+		if len(expr.Args) == 1 {
+			return ix.evalIntDepth(expr.Args[0], iota, depth+1)
+		}
+	case *ast.BinaryExpr:
+		x, ok := ix.evalIntDepth(expr.X, iota, depth+1)
+		if !ok {
+			return 0, false
+		}
+		y, ok := ix.evalIntDepth(expr.Y, iota, depth+1)
+		if !ok {
+			return 0, false
+		}
+		switch expr.Op {
+		case token.ADD:
+			return x + y, true
+		case token.SUB:
+			return x - y, true
+		case token.MUL:
+			return x * y, true
+		case token.SHL:
+			return x << y, true
+		}
+	}
+	return 0, false
+}
+
+// isIntFormat matches "strconv.Itoa(x)" and "strconv.FormatInt(int64(x), base)"
+// where base is a literal, and returns x.
+func isIntFormat(n ast.Node) (ast.Expr, int, bool) {
+	call, ok := n.(*ast.CallExpr)
+	if !ok {
+		return nil, 0, false
+	}
+	pkg, fun, ok := isSelector(call.Fun)
+	if !ok || pkg.Name != "strconv" {
+		return nil, 0, false
+	}
+
+	base := 10
+	switch {
+	case fun == "Itoa" && len(call.Args) == 1:
+	case (fun == "FormatInt" || fun == "FormatUint") && len(call.Args) == 2:
+		lit, ok := call.Args[1].(*ast.BasicLit)
+		if !ok || lit.Kind != token.INT {
+			return nil, 0, false
+		}
+		b, err := strconv.Atoi(lit.Value)
+		if err != nil {
+			return nil, 0, false
+		}
+		base = b
+	default:
+		return nil, 0, false
+	}
+
+	arg := call.Args[0]
+	// int64(x.key)
+	if conv, ok := arg.(*ast.CallExpr); ok && len(conv.Args) == 1 {
+		if _, ok := conv.Fun.(*ast.Ident); ok {
+			arg = conv.Args[0]
+		}
+	}
+	return arg, base, true
+}
+
+// caseItem is a test case in a table.
+type caseItem struct {
+	// node is the test case, or the key-value pair for a map entry
+	node ast.Node
+	// name is the expression giving the name of the test case
+	name ast.Expr
+	// index is the position of the test case in the table
+	index int
+}
+
+// tableItems returns the test cases in init, the expression initializing
+// the table, along with their names given by the field key.
+func (ix *index) tableItems(init ast.Expr, key string) []caseItem {
+	k := itemsKey{init, key, ix.matchFieldFold(), ix.maxSearchDepth(), nameOptionsVersion()}
+	ix.tables.RLock()
+	items, ok := ix.tables.items[k]
+	ix.tables.RUnlock()
+	if !ok {
+		items = ix.readTableItems(init, key)
+		ix.tables.Lock()
+		ix.tables.items[k] = items
+		ix.tables.Unlock()
+	}
+	// clipped, so that appending to it does not write to the cache
+	return items[:len(items):len(items)]
+}
+
+// itemsKey identifies the test cases of a table as read with a configuration.
+type itemsKey struct {
+	init  ast.Expr
+	key   string
+	fold  bool
+	depth int
+	// options is the version of the registered name options
+	options int
+}
+
+// itemsCache holds the test cases of the tables, which are read once for all
+// the calls looking them up rather than for each of them.
+type itemsCache struct {
+	sync.RWMutex
+	items map[itemsKey][]caseItem
+}
+
+// readTableItems is tableItems, uncached.
+func (ix *index) readTableItems(init ast.Expr, key string) []caseItem {
+	testcases, ok := init.(*ast.CompositeLit)
+	if !ok {
+		return nil
+	}
+
+	var testcaseType ast.Expr
+	if t, ok := testcases.Type.(*ast.ArrayType); ok {
+		// a generic type, eg. testcase[T], has the fields of its declaration,
+		// and so has a pointer type, eg. *testcase
+		testcaseType = uninstantiate(unstar(t.Elt))
+		if ident, ok := testcaseType.(*ast.Ident); ok {
+			testcaseType = ix.objToTypeDecl[ident.Obj]
+			if testcaseType == nil {
+				ix.logf("could not resolve type of %s", ident.Name)
+				return nil
+			}
+		}
+	} else if m, ok := testcases.Type.(*ast.MapType); ok {
+		testcaseType = m
+	} else {
+		// testcases should be an array eg.
+		//   testcases := []testcase{ ... }
+		// or a map eg.
+		//   testcases := map[string]testcase{ ... }
+		debugf("unexpected testcase type: %#v", testcases.Type)
+		return nil
+	}
+
+	// the position of the name in the positional literals, which is nested
+	// for a field promoted from an embedded struct
+	path := ix.fieldPath(testcaseType, key, 0)
+
+	var items []caseItem
+	for index, testcase := range testcases.Elts {
+		if kv, ok := testcase.(*ast.KeyValueExpr); ok {
+			items = append(items, caseItem{kv, kv.Key, index})
+			continue
+		}
+
+		if call, ok := testcase.(*ast.CallExpr); ok {
+			// makeCase(withName("foo"), ...)
+			if name := nameOption(call); name != nil {
+				items = append(items, caseItem{call, name, index})
+			}
+			continue
+		}
+
+		if unary, ok := testcase.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+			// &testcase{ ... }
+			testcase = unary.X
+		}
+
+		if ident, ok := testcase.(*ast.Ident); ok {
+			// a test case declared as a variable eg.
+			//   foo := testcase{ ... }
+			//   testcases := []testcase{ foo, ... }
+			if lit, ok := ix.objToVarInit[ident.Obj].(*ast.CompositeLit); ok {
+				testcase = lit
+			}
+		}
+
+		testcase, ok := testcase.(*ast.CompositeLit)
+		if !ok {
+			// testcase should be a struct literal eg.
+			//   { name: "foo", ... }
+			// or
+			//   { "foo", ... }
+			continue
+		}
+
+		// a keyed literal is matched by the key only, in whatever order the
+		// fields are given, and never by the position of its fields
+		keyed := len(testcase.Elts) > 0
+		if keyed {
+			_, keyed = testcase.Elts[0].(*ast.KeyValueExpr)
+		}
+
+		if !keyed {
+			// { <value>, ...}
+			if field := positionalField(testcase, path); field != nil {
+				items = append(items, caseItem{testcase, field, index})
+			}
+			continue
+		}
+		// { <key>: <value>, ... }
+		if field := ix.fieldValue(testcase, key); field != nil {
+			items = append(items, caseItem{testcase, field, index})
+		}
 	}
 
-	for i, field := range typ.Fields.List {
+	return items
+}
+
+var nameOptions = struct {
+	sync.RWMutex
+	names map[string]bool
+	// version counts the changes to names, on which the test cases read
+	// from the tables depend
+	version int
+}{names: map[string]bool{}}
+
+// RegisterNameOption registers funcName as a functional option that sets the
+// name of a test case, so that rows built by a constructor, eg.
+//
+//	makeCase(withName("foo"), withWant(1))
+//
+// can be located by the string literal passed to the option.
+// It is meant to be called from init or TestMain.
+func RegisterNameOption(funcName string) {
+	nameOptions.Lock()
+	defer nameOptions.Unlock()
+	nameOptions.names[funcName] = true
+	nameOptions.version++
+}
+
+// unregisterNameOption undoes RegisterNameOption, for tests.
+func unregisterNameOption(funcName string) {
+	nameOptions.Lock()
+	defer nameOptions.Unlock()
+	delete(nameOptions.names, funcName)
+	nameOptions.version++
+}
+
+// nameOptionsVersion returns the version of the registered name options.
+func nameOptionsVersion() int {
+	nameOptions.RLock()
+	defer nameOptions.RUnlock()
+	return nameOptions.version
+}
+
+// nameOption returns the argument given to a registered name option
+// in the arguments of call.
+func nameOption(call *ast.CallExpr) ast.Expr {
+	for _, arg := range call.Args {
+		opt, ok := arg.(*ast.CallExpr)
+		if !ok || len(opt.Args) != 1 {
+			continue
+		}
+		var name string
+		if ident, ok := opt.Fun.(*ast.Ident); ok {
+			name = ident.Name
+		} else if _, sel, ok := isSelector(opt.Fun); ok {
+			name = sel
+		}
+		nameOptions.RLock()
+		registered := nameOptions.names[name]
+		nameOptions.RUnlock()
+		if registered {
+			return opt.Args[0]
+		}
+	}
+	return nil
+}
+
+// stringValue returns the value of expr if it is a string literal.
+func stringValue(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return "", false
+	}
+	return s, true
+}
+
+// stringValue returns the value of expr if it is a string literal, or an
+// element of a string slice variable indexed by a constant, eg.
+//
+//	var names = []string{"foo", "bar"}
+//	testcases := []testcase{{name: names[0]}, {name: names[1]}}
+//
+// or a call to a function of the file simply returning a string, eg.
+//
+//	func keyOf(s string) string { return "prefix_" + s }
+//	testcases := map[string]testcase{keyOf("foo"): {...}}
+func (ix *index) stringValue(expr ast.Expr) (string, bool) {
+	return ix.evalString(expr, nil, 0)
+}
+
+// evalString is stringValue evaluating the parameters of a function as
+// given by params.
+func (ix *index) evalString(expr ast.Expr, params map[*ast.Object]string, depth int) (string, bool) {
+	if depth > ix.maxSearchDepth() {
+		ix.logf("maximum search depth %d exceeded at %s", ix.maxSearchDepth(), types.ExprString(expr))
+		return "", false
+	}
+
+	switch expr := expr.(type) {
+	case *ast.IndexExpr:
+		ident, ok := expr.X.(*ast.Ident)
+		if !ok {
+			return "", false
+		}
+		lit, ok := ix.objToVarInit[ident.Obj].(*ast.CompositeLit)
+		if !ok {
+			return "", false
+		}
+		if _, ok := lit.Type.(*ast.ArrayType); !ok {
+			return "", false
+		}
+		i, ok := ix.intValue(expr.Index)
+		if !ok || i < 0 || i >= int64(len(lit.Elts)) {
+			return "", false
+		}
+		return stringValue(lit.Elts[i])
+	case *ast.Ident:
+		if s, ok := params[expr.Obj]; ok {
+			return s, true
+		}
+		// const name = "foo"
+		if value, ok := ix.objToConstValue[expr.Obj]; ok {
+			return ix.evalString(value, nil, depth+1)
+		}
+		return "", false
+	case *ast.ParenExpr:
+		return ix.evalString(expr.X, params, depth+1)
+	case *ast.BinaryExpr:
+		if expr.Op != token.ADD {
+			return "", false
+		}
+		x, ok := ix.evalString(expr.X, params, depth+1)
+		if !ok {
+			return "", false
+		}
+		y, ok := ix.evalString(expr.Y, params, depth+1)
+		return x + y, ok
+	case *ast.CallExpr:
+		return ix.evalCall(expr, params, depth)
+	}
+	return stringValue(expr)
+}
+
+// evalCall returns the string returned by call, a call to a function of the
+// file whose body is a single return statement.
+func (ix *index) evalCall(call *ast.CallExpr, params map[*ast.Object]string, depth int) (string, bool) {
+	fun, ok := call.Fun.(*ast.Ident)
+	if !ok || fun.Obj == nil || fun.Obj.Kind != ast.Fun {
+		return "", false
+	}
+	decl, ok := fun.Obj.Decl.(*ast.FuncDecl)
+	if !ok || decl.Body == nil || len(decl.Body.List) != 1 {
+		return "", false
+	}
+	ret, ok := decl.Body.List[0].(*ast.ReturnStmt)
+	if !ok || len(ret.Results) != 1 {
+		return "", false
+	}
+
+	args := map[*ast.Object]string{}
+	i := 0
+	for _, field := range decl.Type.Params.List {
+		for _, name := range field.Names {
+			if i >= len(call.Args) {
+				return "", false
+			}
+			arg, ok := ix.evalString(call.Args[i], params, depth+1)
+			if !ok {
+				return "", false
+			}
+			args[name.Obj] = arg
+			i++
+		}
+	}
+	if i != len(call.Args) {
+		return "", false
+	}
+	return ix.evalString(ret.Results[0], args, depth+1)
+}
+
+// MatchFieldFold makes the names of the fields match regardless of case,
+// eg. for tools looking up test cases by field names from external metadata
+// such as JSON. Go compares them exactly, which is the default.
+var MatchFieldFold = false
+
+// fieldNameEqual reports whether the field names a and b match.
+func (ix *index) fieldNameEqual(a, b string) bool {
+	if ix.matchFieldFold() {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+func (ix *index) findStructFieldIndex(t ast.Expr, name string) int {
+	typ, ok := t.(*ast.StructType)
+	if !ok {
+		return -1
+	}
+
+	// a positional literal has a value per name, eg. two for "a, b string",
+	// and one per embedded field
+	var i int
+	for _, field := range typ.Fields.List {
+		if len(field.Names) == 0 {
+			i++
+			continue
+		}
 		for _, ident := range field.Names {
-			if ident.Name == name {
+			if ix.fieldNameEqual(ident.Name, name) {
 				return i
 			}
+			i++
 		}
 	}
 
 	return -1
 }
 
+// fieldPath is like findStructFieldIndex but also finds the fields promoted
+// from the structs embedded in t, declared in the file, giving the position
+// of the field in each nested positional literal, eg. [1 0] for name in
+//
+//	type base struct { name string }
+//	struct { line int; base }
+//
+// It returns nil if t has no such field.
+func (ix *index) fieldPath(t ast.Expr, name string, depth int) []int {
+	if i := ix.findStructFieldIndex(t, name); i >= 0 {
+		return []int{i}
+	}
+	typ, ok := t.(*ast.StructType)
+	if !ok {
+		return nil
+	}
+	if depth > ix.maxSearchDepth() {
+		ix.logf("maximum search depth %d exceeded at embedded fields of %s", ix.maxSearchDepth(), types.ExprString(t))
+		return nil
+	}
+
+	var i int
+	for _, field := range typ.Fields.List {
+		if len(field.Names) > 0 {
+			i += len(field.Names)
+			continue
+		}
+		if ident, ok := uninstantiate(unstar(field.Type)).(*ast.Ident); ok {
+			if path := ix.fieldPath(ix.objToTypeDecl[ident.Obj], name, depth+1); path != nil {
+				return append([]int{i}, path...)
+			}
+		}
+		i++
+	}
+	return nil
+}
+
+// hasUnresolvedEmbedding reports whether the struct type t embeds, possibly
+// through other embedded structs, a type which is not declared in the
+// package, and so whose fields are unknown.
+func (ix *index) hasUnresolvedEmbedding(t ast.Expr, depth int) bool {
+	typ, ok := t.(*ast.StructType)
+	if !ok {
+		return false
+	}
+	if depth > ix.maxSearchDepth() {
+		ix.logf("maximum search depth %d exceeded at embedded fields of %s", ix.maxSearchDepth(), types.ExprString(t))
+		return true
+	}
+
+	for _, field := range typ.Fields.List {
+		if len(field.Names) > 0 {
+			continue
+		}
+		ident, ok := uninstantiate(unstar(field.Type)).(*ast.Ident)
+		if !ok {
+			// eg. url.URL
+			return true
+		}
+		decl := ix.objToTypeDecl[ident.Obj]
+		if decl == nil || ix.hasUnresolvedEmbedding(decl, depth+1) {
+			return true
+		}
+	}
+	return false
+}
+
+// positionalField returns the value at path, as given by fieldPath, in lit,
+// a positional struct literal, or nil if there is none.
+func positionalField(lit *ast.CompositeLit, path []int) ast.Expr {
+	if path == nil {
+		return nil
+	}
+	var expr ast.Expr = lit
+	for _, i := range path {
+		if unary, ok := expr.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+			// an embedded pointer, eg. &base{ ... }
+			expr = unary.X
+		}
+		lit, ok := expr.(*ast.CompositeLit)
+		if !ok || i >= len(lit.Elts) {
+			return nil
+		}
+		expr = lit.Elts[i]
+	}
+	return expr
+}
+
+// Logger receives the diagnostics of the lookups, eg. why an argument is
+// not supported. It discards them by default.
+var Logger = log.New(io.Discard, "", 0)
+
+// SetLogger sets Logger to l, eg. log.Default() to debug a lookup failing to
+// locate a test case, or discards the diagnostics if l is nil.
+func SetLogger(l *log.Logger) {
+	if l == nil {
+		l = log.New(io.Discard, "", 0)
+	}
+	Logger = l
+}
+
 func logf(format string, args ...interface{}) {
-	log.Printf(format, args...)
+	Logger.Printf(format, args...)
+}
+
+// logTo writes to l if not nil, or else to Logger.
+func logTo(l *log.Logger, format string, args ...interface{}) {
+	if l != nil {
+		l.Printf(format, args...)
+		return
+	}
+	logf(format, args...)
+}
+
+// logf writes to the Logger of the Finder of ix if it has one, or else to
+// Logger.
+func (ix *index) logf(format string, args ...interface{}) {
+	if ix.finder != nil {
+		logTo(ix.finder.Logger, format, args...)
+		return
+	}
+	logf(format, args...)
+}
+
+// maxSearchDepth is MaxSearchDepth of the Finder of ix if it has one.
+func (ix *index) maxSearchDepth() int {
+	if ix.finder != nil {
+		return ix.finder.MaxSearchDepth
+	}
+	return MaxSearchDepth
+}
+
+// nameTransform is NameTransform of the Finder of ix if it has one.
+func (ix *index) nameTransform() func(string) string {
+	if ix.finder != nil {
+		return ix.finder.NameTransform
+	}
+	return NameTransform
+}
+
+// matchFieldFold is MatchFieldFold of the Finder of ix if it has one.
+func (ix *index) matchFieldFold() bool {
+	if ix.finder != nil {
+		return ix.finder.MatchFieldFold
+	}
+	return MatchFieldFold
 }
 
 const debug = false
 
 func debugf(format string, args ...interface{}) {
 	if debug {
-		log.Printf("debug: "+format, args...)
+		Logger.Printf("debug: "+format, args...)
 	}
 }
@@ -1,12 +1,24 @@
 package dataloc_test
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
 	"runtime"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
-	// calling by dataloc.L() is important; L() without package name won't work
-	"github.com/motemen/go-testutil/dataloc"
+	"github.com/client9/go-testutil/dataloc"
 )
 
 var file = "dataloc_test.go"
@@ -92,3 +104,2909 @@ func TestL_caseTypeMap(t *testing.T) {
 		})
 	}
 }
+
+func TestL_caseConfigField(t *testing.T) {
+	type testcase struct {
+		name string
+		line int
+	}
+
+	type config struct {
+		verbose bool
+		cases   []testcase
+	}
+
+	cfg := config{
+		cases: []testcase{
+			{name: "keyed", line: __line__()},
+			{"unkeyed", __line__()},
+		},
+	}
+
+	for _, test := range cfg.cases {
+		t.Run(test.name, func(t *testing.T) {
+			if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+				t.Errorf("expected %q, got %q", expected, got)
+			}
+		})
+	}
+}
+
+func TestL_resolveSymlinks(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	link := filepath.Join(t.TempDir(), "link")
+	if err := os.Symlink(wd, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	// os.Getwd reports $PWD as long as it refers to the working directory
+	t.Setenv("PWD", link)
+	if err := os.Chdir(link); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	defer func(v bool) { dataloc.ResolveSymlinks = v }(dataloc.ResolveSymlinks)
+
+	tests := []struct {
+		name string
+		line int
+	}{
+		{"symlinked", __line__()},
+	}
+
+	for _, test := range tests {
+		dataloc.ResolveSymlinks = false
+		if got, unexpected := dataloc.L(test.name), fmt.Sprintf("%s:%d", file, test.line); got == unexpected {
+			t.Errorf("expected a path through the symlink, got %q", got)
+		}
+
+		dataloc.ResolveSymlinks = true
+		if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+	}
+}
+
+type optionCase struct {
+	name string
+	line int
+}
+
+type caseOption func(*optionCase)
+
+func withName(name string) caseOption {
+	return func(c *optionCase) { c.name = name }
+}
+
+func withLine(line int) caseOption {
+	return func(c *optionCase) { c.line = line }
+}
+
+func makeCase(opts ...caseOption) optionCase {
+	var c optionCase
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+func TestL_caseFunctionalOptions(t *testing.T) {
+	dataloc.RegisterNameOption("withName")
+	t.Cleanup(func() { dataloc.UnregisterNameOption("withName") })
+
+	tests := []optionCase{
+		makeCase(withName("first"), withLine(__line__())),
+		makeCase(withLine(__line__()), withName("second")),
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+				t.Errorf("expected %q, got %q", expected, got)
+			}
+		})
+	}
+}
+
+func TestL_caseParallelCapture(t *testing.T) {
+	tests := []struct {
+		name string
+		line int
+	}{
+		{name: "keyed", line: __line__()},
+		{"unkeyed", __line__()},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+				t.Errorf("expected %q, got %q", expected, got)
+			}
+		})
+	}
+}
+
+func TestL_caseMapOfSlices(t *testing.T) {
+	grouped := map[string][]struct {
+		name string
+		line int
+	}{
+		"group1": {{name: "a", line: __line__()}, {name: "b", line: __line__()}},
+		"group2": {{name: "a", line: __line__()}},
+	}
+
+	for group, tests := range grouped {
+		for _, test := range tests {
+			t.Run(group+"/"+test.name, func(t *testing.T) {
+				if got, expected := dataloc.L(group), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+					t.Errorf("expected %q, got %q", expected, got)
+				}
+			})
+		}
+	}
+}
+
+func TestL_basePath(t *testing.T) {
+	base, err := filepath.Abs("..")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(s string) { dataloc.BasePath = s }(dataloc.BasePath)
+	dataloc.BasePath = base
+
+	tests := []struct {
+		name string
+		line int
+	}{
+		{"relative to base", __line__()},
+	}
+
+	for _, test := range tests {
+		if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", filepath.Join("dataloc", file), test.line); got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+	}
+}
+
+type valueNameCase struct {
+	label string
+	line  int
+}
+
+func (c valueNameCase) name() string { return c.label }
+
+type pointerNameCase struct {
+	label string
+	line  int
+}
+
+func (c *pointerNameCase) name() string { return c.label }
+
+func TestL_caseNameMethod(t *testing.T) {
+	valueTests := []valueNameCase{
+		{label: "keyed", line: __line__()},
+		{"unkeyed", __line__()},
+	}
+
+	for _, test := range valueTests {
+		t.Run(test.name(), func(t *testing.T) {
+			if got, expected := dataloc.L(test.name()), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+				t.Errorf("expected %q, got %q", expected, got)
+			}
+		})
+	}
+
+	pointerTests := []pointerNameCase{
+		{label: "keyed", line: __line__()},
+		{"unkeyed", __line__()},
+	}
+
+	for _, test := range pointerTests {
+		t.Run(test.name(), func(t *testing.T) {
+			if got, expected := dataloc.L(test.name()), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+				t.Errorf("expected %q, got %q", expected, got)
+			}
+		})
+	}
+}
+
+func TestL_binaryFallback(t *testing.T) {
+	defer func(f func(string) ([]byte, error)) { *dataloc.ReadFile = f }(*dataloc.ReadFile)
+	*dataloc.ReadFile = func(name string) ([]byte, error) {
+		return nil, os.ErrNotExist
+	}
+	dataloc.Reset()
+	defer dataloc.Reset()
+
+	defer func(v bool) { dataloc.BinaryFallback = v }(dataloc.BinaryFallback)
+
+	dataloc.BinaryFallback = false
+	if got, expected := dataloc.L("no source"), ""; got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+
+	dataloc.BinaryFallback = true
+	if got, expected := dataloc.L("no source"), fmt.Sprintf("%s:%d", file, __line__()); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestL_caseIntFormat(t *testing.T) {
+	tests := []struct {
+		id   int
+		line int
+	}{
+		{id: 1, line: __line__()},
+		{0x10, __line__()},
+	}
+
+	for _, test := range tests {
+		t.Run(strconv.Itoa(test.id), func(t *testing.T) {
+			if got, expected := dataloc.L(strconv.Itoa(test.id)), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+				t.Errorf("expected %q, got %q", expected, got)
+			}
+			if got, expected := dataloc.L(strconv.FormatInt(int64(test.id), 2)), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+				t.Errorf("expected %q, got %q", expected, got)
+			}
+		})
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	tests := []struct {
+		name string
+		line int
+	}{
+		{"dup", __line__()},
+		{"unique", __line__()},
+		{"dup", __line__()},
+		{"dup", __line__()},
+	}
+
+	for _, test := range tests {
+		_ = dataloc.L(test.name)
+	}
+
+	dups, err := dataloc.Duplicates()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dups) != 1 {
+		t.Fatalf("expected 1 duplicate name, got %v", dups)
+	}
+
+	expected := []string{
+		fmt.Sprintf("%s:%d", file, tests[0].line),
+		fmt.Sprintf("%s:%d", file, tests[2].line),
+		fmt.Sprintf("%s:%d", file, tests[3].line),
+	}
+	if len(dups["dup"]) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, dups["dup"])
+	}
+	for i, loc := range dups["dup"] {
+		if got := loc.String(); got != expected[i] {
+			t.Errorf("expected %q, got %q", expected[i], got)
+		}
+	}
+}
+
+func TestL_caseIndexFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		line int
+	}{
+		{"same", __line__()},
+		{"same", __line__()},
+		{"other", __line__()},
+	}
+
+	for i, test := range tests {
+		name := fmt.Sprintf("%s[%d]", test.name, i)
+		t.Run(name, func(t *testing.T) {
+			if got, expected := dataloc.L(fmt.Sprintf("%s[%d]", test.name, i)), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+				t.Errorf("expected %q, got %q", expected, got)
+			}
+		})
+	}
+}
+
+func TestL_caseAffixedName(t *testing.T) {
+	tests := []struct {
+		name string
+		line int
+	}{
+		{name: "keyed", line: __line__()},
+		{"unkeyed", __line__()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name+"_variant", func(t *testing.T) {
+			if got, expected := dataloc.L(test.name+"_variant"), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+				t.Errorf("expected %q, got %q", expected, got)
+			}
+			if got, expected := dataloc.L("pre_"+test.name+"_post"), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+				t.Errorf("expected %q, got %q", expected, got)
+			}
+		})
+	}
+}
+
+func TestResolveFile(t *testing.T) {
+	fixture := filepath.Join("testdata", "resolve.go")
+	resolved, err := dataloc.ResolveFile(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[int]dataloc.Resolved{
+		18: {},
+		21: {Name: "b", Location: dataloc.Location{File: fixture, Line: 14, Column: 3}},
+		22: {Name: "missing"},
+		23: {Name: "a", Location: dataloc.Location{File: fixture, Line: 13, Column: 3}},
+	}
+	if len(resolved) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, resolved)
+	}
+	for line, r := range expected {
+		if got := resolved[line]; got != r {
+			t.Errorf("line %d: expected %+v, got %+v", line, r, got)
+		}
+	}
+}
+
+func TestL_caseNestedMap(t *testing.T) {
+	tests := map[string]map[string]struct {
+		line int
+	}{
+		"group1": {
+			"a": {line: __line__()},
+			"b": {line: __line__()},
+		},
+		"group2": {
+			"a": {line: __line__()},
+		},
+	}
+
+	for group, cases := range tests {
+		for name, test := range cases {
+			t.Run(group+"/"+name, func(t *testing.T) {
+				if got, expected := dataloc.L(group+"/"+name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+					t.Errorf("expected %q, got %q", expected, got)
+				}
+			})
+		}
+	}
+}
+
+func TestL_nameTransform(t *testing.T) {
+	defer func(f func(string) string) { dataloc.NameTransform = f }(dataloc.NameTransform)
+	dataloc.NameTransform = func(s string) string {
+		return strings.ReplaceAll(strings.ToLower(s), " ", "-")
+	}
+
+	tests := []struct {
+		name string
+		line int
+	}{
+		{name: "With Spaces", line: __line__()},
+		{"already-dashed", __line__()},
+	}
+
+	for _, test := range tests {
+		test.name = strings.ReplaceAll(strings.ToLower(test.name), " ", "-")
+		t.Run(test.name, func(t *testing.T) {
+			if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+				t.Errorf("expected %q, got %q", expected, got)
+			}
+		})
+	}
+}
+
+type groupedVarCase struct {
+	name string
+	line int
+}
+
+var (
+	groupedVarA     = groupedVarCase{name: "a", line: __line__()}
+	groupedVarB     = groupedVarCase{"b", __line__()}
+	groupedVarCases = []groupedVarCase{groupedVarA, groupedVarB}
+)
+
+func TestL_caseGroupedVarRows(t *testing.T) {
+	for _, test := range groupedVarCases {
+		t.Run(test.name, func(t *testing.T) {
+			if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+				t.Errorf("expected %q, got %q", expected, got)
+			}
+		})
+	}
+}
+
+func TestAnnotationFull(t *testing.T) {
+	workspace, err := filepath.Abs("..")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		line int
+	}{
+		{name: "keyed", line: __line__()},
+		{"unkeyed", __line__()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("GITHUB_WORKSPACE", "")
+			if got, expected := dataloc.AnnotationFull(test.name, "warning"), fmt.Sprintf("::warning file=%s,line=%d,col=3::", file, test.line); got != expected {
+				t.Errorf("expected %q, got %q", expected, got)
+			}
+
+			t.Setenv("GITHUB_WORKSPACE", workspace)
+			if got, expected := dataloc.AnnotationFull(test.name, "error"), fmt.Sprintf("::error file=dataloc/%s,line=%d,col=3::", file, test.line); got != expected {
+				t.Errorf("expected %q, got %q", expected, got)
+			}
+		})
+	}
+
+	if got := dataloc.AnnotationFull("missing", "notice"); got != "" {
+		t.Errorf("expected empty annotation, got %q", got)
+	}
+}
+
+type caseKind int
+
+const (
+	kindFoo caseKind = iota
+	kindBar
+)
+
+var caseKindNames = map[caseKind]string{
+	kindFoo: "foo",
+	kindBar: "bar",
+}
+
+func TestL_caseEnumNameMap(t *testing.T) {
+	tests := []struct {
+		kind caseKind
+		line int
+	}{
+		{kind: kindFoo, line: __line__()},
+		{kindBar, __line__()},
+	}
+
+	for _, test := range tests {
+		t.Run(caseKindNames[test.kind], func(t *testing.T) {
+			if got, expected := dataloc.L(caseKindNames[test.kind]), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+				t.Errorf("expected %q, got %q", expected, got)
+			}
+		})
+	}
+}
+
+func TestLocateNth(t *testing.T) {
+	tests := []struct {
+		name string
+		line int
+	}{
+		{"rerun", __line__()},
+		{"other", __line__()},
+		{"rerun", __line__()},
+		{"rerun", __line__()},
+	}
+
+	var n int
+	for _, test := range tests {
+		if test.name != "rerun" {
+			continue
+		}
+		loc, err := dataloc.LocateNth(test.name, n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, expected := loc.String(), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("#%d: expected %q, got %q", n, expected, got)
+		}
+		n++
+	}
+
+	for _, test := range tests[:1] {
+		if _, err := dataloc.LocateNth(test.name, 3); err == nil {
+			t.Error("expected an error for a missing index")
+		}
+		if _, err := dataloc.LocateNth(test.name, -1); err == nil {
+			t.Error("expected an error for a negative index")
+		}
+	}
+}
+
+type initCase struct {
+	name string
+	line int
+}
+
+var initCases []initCase
+
+func init() {
+	initCases = append(initCases, initCase{name: "keyed", line: __line__()})
+	initCases = append(initCases,
+		initCase{"unkeyed", __line__()},
+		initCase{"another", __line__()},
+	)
+}
+
+func TestL_caseAppendedInInit(t *testing.T) {
+	for _, test := range initCases {
+		t.Run(test.name, func(t *testing.T) {
+			if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+				t.Errorf("expected %q, got %q", expected, got)
+			}
+		})
+	}
+}
+
+func TestIndexPackage(t *testing.T) {
+	dir := filepath.Join("testdata", "pkg")
+	index, err := dataloc.IndexPackage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string][]string{
+		filepath.Join(dir, "a_test.go"): {"a1@13", "a2@14"},
+		filepath.Join(dir, "b_test.go"): {"b1@10"},
+		// looked up by c_test.go
+		filepath.Join(dir, "shared_test.go"): {"s1@6"},
+	}
+	if len(index) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, index)
+	}
+	for file, cases := range expected {
+		var got []string
+		for _, c := range index[file] {
+			if c.Location.File != file {
+				t.Errorf("expected %q, got %q", file, c.Location.File)
+			}
+			got = append(got, fmt.Sprintf("%s@%d", c.Name, c.Location.Line))
+		}
+		if fmt.Sprint(got) != fmt.Sprint(cases) {
+			t.Errorf("%s: expected %v, got %v", file, cases, got)
+		}
+	}
+}
+
+func TestL_caseNameFuncField(t *testing.T) {
+	tests := []struct {
+		nameFunc func() string
+		line     int
+	}{
+		{nameFunc: func() string { return "keyed" }, line: __line__()},
+		{func() string { return "unkeyed" }, __line__()},
+	}
+
+	for _, test := range tests {
+		nameOf := test.nameFunc
+		t.Run(nameOf(), func(t *testing.T) {
+			if got, expected := dataloc.L(nameOf()), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+				t.Errorf("expected %q, got %q", expected, got)
+			}
+		})
+	}
+}
+
+func TestL_unsupportedCallDiagnostic(t *testing.T) {
+	var buf bytes.Buffer
+	dataloc.SetLogger(log.New(&buf, "", 0))
+	defer dataloc.SetLogger(nil)
+
+	tests := []struct {
+		name string
+	}{
+		{"unsupported"},
+	}
+
+	nameOfCase := func(i int) func() string {
+		return func() string { return tests[i].name }
+	}
+
+	for i := range tests {
+		nameOf := nameOfCase(i)
+		if got, expected := dataloc.L(nameOf()), "(unknown)"; got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+	}
+
+	if expected := "unsupported form of argument: nameOf()"; !strings.Contains(buf.String(), expected) {
+		t.Errorf("expected diagnostic %q, got %q", expected, buf.String())
+	}
+}
+
+type urlCase struct {
+	url.URL
+	line int
+}
+
+func TestLocateNth_promotedFromAnotherPackage(t *testing.T) {
+	// Path is promoted from url.URL, whose fields are unknown
+	tests := []urlCase{
+		{URL: url.URL{Path: "/a"}, line: __line__()},
+	}
+
+	for _, test := range tests {
+		loc, err := dataloc.LocateNth(test.Path, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, expected := loc.String(), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+	}
+}
+
+func TestLocateInBlob_fieldNotFound(t *testing.T) {
+	// not valid Go, as label is not a field of testcase
+	src := []byte(`package fixture
+
+import "github.com/client9/go-testutil/dataloc"
+
+type testcase struct {
+	name string
+}
+
+func TestMissing(t *testing.T) {
+	tests := []testcase{
+		{"a"},
+	}
+	for _, test := range tests {
+		t.Log(dataloc.L(test.label))
+	}
+}
+`)
+	_, err := dataloc.LocateInBlob(src, "missing_test.go", "a")
+	if !errors.Is(err, dataloc.ErrFieldNotFound) {
+		t.Fatalf("expected ErrFieldNotFound, got %v", err)
+	}
+	if expected := "label in type testcase"; !strings.Contains(err.Error(), expected) {
+		t.Errorf("expected error to mention %q, got %q", expected, err)
+	}
+}
+
+const (
+	iotaCaseA = iota
+	iotaCaseB
+	iotaCaseC = iota * 10
+)
+
+func TestL_caseIotaMapKeys(t *testing.T) {
+	tests := map[int]struct {
+		line int
+	}{
+		iotaCaseA: {line: __line__()},
+		iotaCaseB: {line: __line__()},
+		iotaCaseC: {line: __line__()},
+	}
+
+	for id, test := range tests {
+		t.Run(strconv.Itoa(id), func(t *testing.T) {
+			if got, expected := dataloc.L(strconv.Itoa(id)), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+				t.Errorf("expected %q, got %q", expected, got)
+			}
+		})
+	}
+
+	if iotaCaseB != 1 {
+		t.Fatalf("expected iotaCaseB to be 1, got %d", iotaCaseB)
+	}
+}
+
+func TestLOrEmpty(t *testing.T) {
+	tests := []struct {
+		name string
+		line int
+	}{
+		{name: "keyed", line: __line__()},
+		{"unkeyed", __line__()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, expected := dataloc.LOrEmpty(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+				t.Errorf("expected %q, got %q", expected, got)
+			}
+		})
+	}
+
+	for _, test := range tests {
+		test.name = "missing"
+		if got := dataloc.LOrEmpty(test.name); got != "" {
+			t.Errorf("expected empty string, got %q", got)
+		}
+	}
+}
+
+func TestL_caseGroupedTable(t *testing.T) {
+	type testcase struct {
+		name string
+		line int
+	}
+
+	type group struct {
+		name  string
+		line  int
+		cases []testcase
+	}
+
+	groups := []group{
+		{
+			name: "shared", line: __line__(),
+			cases: []testcase{
+				{name: "shared", line: __line__()},
+				{name: "inner", line: __line__()},
+			},
+		},
+	}
+
+	for _, g := range groups {
+		t.Run(g.name, func(t *testing.T) {
+			// the group literal starts on the line before its name
+			if got, expected := dataloc.L(g.name), fmt.Sprintf("%s:%d", file, g.line-1); got != expected {
+				t.Errorf("expected %q, got %q", expected, got)
+			}
+		})
+	}
+}
+
+func TestL_maxSearchDepth(t *testing.T) {
+	tests := []struct {
+		name string
+		line int
+	}{
+		{"deep", __line__()},
+	}
+
+	defer func(n int) { dataloc.MaxSearchDepth = n }(dataloc.MaxSearchDepth)
+
+	for _, test := range tests {
+		if got, expected := dataloc.L("<"+"<"+test.name+">"+">"), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+	}
+
+	dataloc.MaxSearchDepth = 2
+	for _, test := range tests {
+		if got, expected := dataloc.L("<"+"<"+test.name+">"+">"), "(unknown)"; got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+	}
+}
+
+func TestL_caseRenamedRangeValue(t *testing.T) {
+	tests := []struct {
+		name string
+		line int
+	}{
+		{name: "keyed", line: __line__()},
+		{"unkeyed", __line__()},
+	}
+
+	for _, testdata := range tests {
+		tc := testdata
+		t.Run(tc.name, func(t *testing.T) {
+			if got, expected := dataloc.L(tc.name), fmt.Sprintf("%s:%d", file, tc.line); got != expected {
+				t.Errorf("expected %q, got %q", expected, got)
+			}
+		})
+	}
+}
+
+func TestStreamIndex(t *testing.T) {
+	dir := filepath.Join("testdata", "pkg")
+	var buf bytes.Buffer
+	if err := dataloc.StreamIndex(dir, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		fmt.Sprintf("a1@%s:13:3", filepath.Join(dir, "a_test.go")),
+		fmt.Sprintf("a2@%s:14:3", filepath.Join(dir, "a_test.go")),
+		fmt.Sprintf("b1@%s:10:2", filepath.Join(dir, "b_test.go")),
+		// looked up twice by c_test.go
+		fmt.Sprintf("s1@%s:6:2", filepath.Join(dir, "shared_test.go")),
+	}
+
+	var got []string
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var c struct {
+			Name   string `json:"name"`
+			File   string `json:"file"`
+			Line   int    `json:"line"`
+			Column int    `json:"column"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &c); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", scanner.Text(), err)
+		}
+		got = append(got, fmt.Sprintf("%s@%s:%d:%d", c.Name, c.File, c.Line, c.Column))
+	}
+
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestL_caseKeyedOutOfOrder(t *testing.T) {
+	type testcase struct {
+		desc string
+		name string
+		line int
+	}
+
+	tests := []testcase{
+		{line: __line__(), desc: "b"},
+		{line: __line__(), name: "a"},
+		{desc: "a", line: __line__(), name: "b"},
+	}
+
+	for _, test := range tests {
+		if test.name == "" {
+			continue
+		}
+		t.Run(test.name, func(t *testing.T) {
+			if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+				t.Errorf("expected %q, got %q", expected, got)
+			}
+		})
+	}
+}
+
+func TestLineText(t *testing.T) {
+	tests := []struct {
+		name string
+		line int
+		text string
+	}{
+		{name: "single", line: __line__(), text: "single line"},
+		{
+			name: "multi",
+			line: __line__() - 2,
+			text: "multiple lines",
+		},
+	}
+
+	expected := map[string]string{
+		"single": `{name: "single", line: __line__(), text: "single line"},`,
+		"multi":  `{`,
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			loc, text, err := dataloc.LineText(test.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, expected := loc.String(), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+				t.Errorf("expected %q, got %q", expected, got)
+			}
+			if got, expected := text, expected[test.name]; got != expected {
+				t.Errorf("expected %q, got %q", expected, got)
+			}
+		})
+	}
+}
+
+func TestLineText_tableInAnotherFile(t *testing.T) {
+	expected := map[string]string{
+		"keyed":   `{name: "keyed", line: __line__()},`,
+		"unkeyed": `{"unkeyed", __line__()},`,
+	}
+
+	for _, test := range sharedCases {
+		loc, text, err := dataloc.LineText(test.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, expected := loc.String(), fmt.Sprintf("%s:%d", "shared_test.go", test.line); got != expected {
+			t.Errorf("%s: expected %q, got %q", test.name, expected, got)
+		}
+		if got, expected := text, expected[test.name]; got != expected {
+			t.Errorf("%s: expected %q, got %q", test.name, expected, got)
+		}
+	}
+}
+
+func TestL_callsSharingLine(t *testing.T) {
+	inputs := []struct {
+		name string
+		line int
+	}{
+		{"input", __line__()},
+	}
+	outputs := []struct {
+		name string
+		line int
+	}{
+		{"output", __line__()},
+	}
+
+	for _, in := range inputs {
+		for _, out := range outputs {
+			gotIn, gotOut := dataloc.L(in.name), dataloc.L(out.name)
+			if expected := fmt.Sprintf("%s:%d", file, in.line); gotIn != expected {
+				t.Errorf("expected %q, got %q", expected, gotIn)
+			}
+			if expected := fmt.Sprintf("%s:%d", file, out.line); gotOut != expected {
+				t.Errorf("expected %q, got %q", expected, gotOut)
+			}
+		}
+	}
+}
+
+func FuzzLocateFuzzSeed(f *testing.F) {
+	lines := map[string]int{}
+
+	f.Add("a", 1, []byte("x"))
+	lines["a"] = __line__() - 1
+	f.Add("b", 2, []byte("y"))
+	lines["b"] = __line__() - 1
+	f.Add("a", int(3), []byte("z"))
+	lines["a3"] = __line__() - 1
+
+	// not a seed, despite the literal argument
+	var wg sync.WaitGroup
+	wg.Add(1)
+	wg.Done()
+
+	f.Fuzz(func(t *testing.T, s string, n int, b []byte) {
+		line, ok := lines[s]
+		if n == 3 {
+			line, ok = lines[s+"3"]
+		}
+		if !ok {
+			t.Skip("not a seed")
+		}
+
+		loc, err := dataloc.LocateFuzzSeed(s, n, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, expected := loc.String(), fmt.Sprintf("%s:%d", file, line); got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+
+		if _, err := dataloc.LocateFuzzSeed(s, n+100, b); err == nil {
+			t.Error("expected an error for a missing seed")
+		}
+		if _, err := dataloc.LocateFuzzSeed(1); !errors.Is(err, dataloc.ErrNotFound) {
+			t.Errorf("expected ErrNotFound for wg.Add(1), got %v", err)
+		}
+	})
+}
+
+var indexedCaseNames = []string{"first", "second"}
+
+func TestL_caseIndexedNames(t *testing.T) {
+	tests := []struct {
+		name string
+		line int
+	}{
+		{name: indexedCaseNames[0], line: __line__()},
+		{indexedCaseNames[1], __line__()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+				t.Errorf("expected %q, got %q", expected, got)
+			}
+		})
+	}
+}
+
+func TestLocateAuto(t *testing.T) {
+	tests := []struct {
+		line int
+		desc string
+		in   string
+	}{
+		{__line__(), "empty input", ""},
+		{__line__(), "spaces", "  "},
+		{line: __line__(), desc: "keyed", in: "input"},
+	}
+
+	for _, test := range tests {
+		loc, err := dataloc.LocateAuto(test.desc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, expected := loc.String(), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("%s: expected %q, got %q", test.desc, expected, got)
+		}
+
+		// in, the second string field, is not taken as the name
+		if test.in == "" {
+			continue
+		}
+		if _, err := dataloc.LocateAuto(test.in); err == nil {
+			t.Errorf("%s: expected an error for %q", test.desc, test.in)
+		}
+	}
+}
+
+func TestL_shadowedTable(t *testing.T) {
+	testcases := []struct {
+		name string
+		line int
+	}{
+		{"outer", __line__()},
+	}
+
+	{
+		testcases := []struct {
+			name string
+			line int
+		}{
+			{"outer", __line__()},
+			{"inner", __line__()},
+		}
+		for _, test := range testcases {
+			if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+				t.Errorf("inner %s: expected %q, got %q", test.name, expected, got)
+			}
+		}
+	}
+
+	for _, test := range testcases {
+		if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("outer %s: expected %q, got %q", test.name, expected, got)
+		}
+	}
+}
+
+type platform struct {
+	os   string
+	arch string
+}
+
+func TestLocateByKeyFields(t *testing.T) {
+	tests := map[platform]int{
+		{"linux", "amd64"}:                    __line__(),
+		{"linux", "arm64"}:                    __line__(),
+		platform{os: "darwin", arch: "arm64"}: __line__(),
+	}
+
+	for p, line := range tests {
+		loc, err := dataloc.LocateByKeyFields(map[string]string{"os": p.os, "arch": p.arch})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, expected := loc.String(), fmt.Sprintf("%s:%d", file, line); got != expected {
+			t.Errorf("%v: expected %q, got %q", p, expected, got)
+		}
+	}
+
+	for range tests {
+		if _, err := dataloc.LocateByKeyFields(map[string]string{"os": "plan9"}); err == nil {
+			t.Error("expected an error for a missing key")
+		}
+	}
+}
+
+func TestMsg(t *testing.T) {
+	tests := []struct {
+		name string
+		line int
+	}{
+		{"found", __line__()},
+	}
+
+	for _, test := range tests {
+		if got, expected := dataloc.Msg(test.name), fmt.Sprintf("case %q @ %s:%d", test.name, file, test.line); got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+	}
+
+	if got, expected := dataloc.Msg("missing"), `case "missing" @ (unknown)`; got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+type helperCase struct {
+	name string
+	line int
+}
+
+var helperCases = map[string]helperCase{
+	"a": {name: "first", line: __line__()},
+	"b": {"second", __line__()},
+}
+
+func helperCaseOf(key string) helperCase {
+	return helperCases[key]
+}
+
+func TestL_helperResult(t *testing.T) {
+	for _, key := range []string{"a", "b"} {
+		if got, expected := dataloc.L(helperCaseOf(key).name), fmt.Sprintf("%s:%d", file, helperCaseOf(key).line); got != expected {
+			t.Errorf("%s: expected %q, got %q", key, expected, got)
+		}
+	}
+}
+
+func pickHelperCase(cases []helperCase) helperCase {
+	return cases[0]
+}
+
+func TestL_pickedResult(t *testing.T) {
+	tests := []helperCase{
+		{name: "picked", line: __line__()},
+		{"not picked", __line__()},
+	}
+
+	if got, expected := dataloc.L(pickHelperCase(tests).name), fmt.Sprintf("%s:%d", file, tests[0].line); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestLocateValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   int
+		column int
+	}{
+		{name: "keyed", line: __line__(), column: 10},
+		{"unkeyed", __line__(), 4},
+	}
+
+	for _, test := range tests {
+		loc, err := dataloc.LocateValue(test.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loc.Line != test.line || loc.Column != test.column {
+			t.Errorf("%s: expected %d:%d, got %d:%d", test.name, test.line, test.column, loc.Line, loc.Column)
+		}
+	}
+}
+
+func TestIndexPackage_generated(t *testing.T) {
+	dir := filepath.Join("testdata", "gen")
+	file := filepath.Join(dir, "tables_gen_test.go")
+	index, err := dataloc.IndexPackage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, c := range index[file] {
+		got = append(got, fmt.Sprintf("%s@%d:%d", c.Name, c.Location.Line, c.Location.Column))
+	}
+	if expected := "[g1@15:2 g2@15:13 g3@16:2]"; fmt.Sprint(got) != expected {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	resolved, err := dataloc.ResolveFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := resolved[24]; r.Name != "g3" || r.Location.Line != 16 {
+		t.Errorf("expected g3 at line 16, got %+v", r)
+	}
+}
+
+func TestIndexPackage_vendor(t *testing.T) {
+	dir := filepath.Join("testdata", "vendor", "example.com", "ext")
+
+	index, err := dataloc.IndexPackage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index) != 0 {
+		t.Errorf("expected vendored files to be skipped, got %v", index)
+	}
+
+	dataloc.SkipVendor = false
+	defer func() { dataloc.SkipVendor = true }()
+
+	index, err = dataloc.IndexPackage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cases := index[filepath.Join(dir, "ext_test.go")]; len(cases) != 1 || cases[0].Name != "vendored" {
+		t.Errorf("expected the vendored test case, got %v", index)
+	}
+
+	dataloc.PathFilter = func(path string) bool { return !strings.HasSuffix(path, "ext_test.go") }
+	defer func() { dataloc.PathFilter = nil }()
+
+	index, err = dataloc.IndexPackage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index) != 0 {
+		t.Errorf("expected filtered files to be skipped, got %v", index)
+	}
+}
+
+func TestIndexPackage_moduleUnderVendor(t *testing.T) {
+	// a checkout of a module under a directory named vendor
+	dir := filepath.Join(t.TempDir(), "vendor", "proj")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/proj\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	src, err := os.ReadFile(filepath.Join("testdata", "vendor", "example.com", "ext", "ext_test.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "ext_test.go"), src, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := dataloc.IndexPackage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index) != 1 {
+		t.Errorf("expected the test case of the module, got %v", index)
+	}
+}
+
+func TestIndexPackage_readsOnce(t *testing.T) {
+	const files = 20
+	dir := t.TempDir()
+	for i := 0; i < files; i++ {
+		src := fmt.Sprintf(`package p
+
+import (
+	"testing"
+
+	"github.com/client9/go-testutil/dataloc"
+)
+
+func Test%d(t *testing.T) {
+	tests := []struct {
+		name string
+	}{
+		{"case%d"},
+	}
+	for _, test := range tests {
+		t.Log(dataloc.L(test.name))
+	}
+}
+`, i, i)
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d_test.go", i)), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var reads int
+	defer func(f func(string) ([]byte, error)) { *dataloc.ReadFile = f }(*dataloc.ReadFile)
+	*dataloc.ReadFile = func(name string) ([]byte, error) {
+		reads++
+		return os.ReadFile(name)
+	}
+
+	index, err := dataloc.IndexPackage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index) != files {
+		t.Errorf("expected the test cases of %d files, got %v", files, index)
+	}
+	if reads != files {
+		t.Errorf("expected each file to be read once, got %d reads", reads)
+	}
+}
+
+type genericCase[T any] struct {
+	name string
+	in   T
+	line int
+}
+
+func genericCases[T any]() []genericCase[T] {
+	return []genericCase[T]{
+		{name: "keyed", line: __line__()},
+		{"unkeyed", *new(T), __line__()},
+	}
+}
+
+func TestL_genericTable(t *testing.T) {
+	testcases := genericCases[int]()
+
+	for _, test := range testcases {
+		if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("%s: expected %q, got %q", test.name, expected, got)
+		}
+	}
+}
+
+func TestEditorURL(t *testing.T) {
+	tests := []struct {
+		name string
+		line int
+	}{
+		{"link", __line__()},
+	}
+
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range tests {
+		got, err := dataloc.EditorURL(test.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := fmt.Sprintf("vscode://file%s:%d:3", filepath.ToSlash(abs), test.line); got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+	}
+
+	defer func(template string) { dataloc.EditorURLTemplate = template }(dataloc.EditorURLTemplate)
+	dataloc.EditorURLTemplate = "idea://open?file={file}&line={line}"
+
+	for _, test := range tests {
+		got, err := dataloc.EditorURL(test.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := fmt.Sprintf("idea://open?file=%s&line=%d", filepath.ToSlash(abs), test.line); got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+	}
+}
+
+func TestLocation_outsidePackage(t *testing.T) {
+	// export_test.go is of package dataloc, this file of dataloc_test
+	_, err := dataloc.LocateOutsidePackage()
+	if err == nil || !strings.Contains(err.Error(), "outside of the package of the caller file") {
+		t.Errorf("expected an error for a test case of another package, got %v", err)
+	}
+}
+
+func TestURLPath(t *testing.T) {
+	tests := []struct {
+		file, expected string
+	}{
+		{"/home/user/foo_test.go", "/home/user/foo_test.go"},
+		// as given by filepath.ToSlash on Windows
+		{"C:/src/foo_test.go", "/C:/src/foo_test.go"},
+	}
+
+	for _, test := range tests {
+		if got := dataloc.URLPath(test.file); got != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.file, test.expected, got)
+		}
+	}
+}
+
+func TestL_caseAssignedByIndex(t *testing.T) {
+	type testcase struct {
+		name string
+		line int
+	}
+
+	testcases := make([]testcase, 2)
+	testcases[0] = testcase{name: "keyed", line: __line__()}
+	testcases[1] = testcase{"unkeyed", __line__()}
+
+	for _, test := range testcases {
+		if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("%s: expected %q, got %q", test.name, expected, got)
+		}
+	}
+
+	byName := make(map[string]testcase)
+	byName["entry"] = testcase{line: __line__()}
+
+	for name, test := range byName {
+		if got, expected := dataloc.L(name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("%s: expected %q, got %q", name, expected, got)
+		}
+	}
+}
+
+func TestStrictModule(t *testing.T) {
+	dataloc.StrictModule = true
+	defer func() { dataloc.StrictModule = false }()
+
+	tests := []struct {
+		name string
+		line int
+	}{
+		{"inside", __line__()},
+	}
+
+	for _, test := range tests {
+		if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+
+		// called through reflect, the caller frame is in the standard library
+		out := reflect.ValueOf(dataloc.LocateValue).Call([]reflect.Value{reflect.ValueOf(test.name)})
+		if err, _ := out[1].Interface().(error); !errors.Is(err, dataloc.ErrOutsideModule) {
+			t.Errorf("expected %v, got %v", dataloc.ErrOutsideModule, err)
+		}
+	}
+
+	out := reflect.ValueOf(dataloc.Duplicates).Call(nil)
+	if err, _ := out[1].Interface().(error); !errors.Is(err, dataloc.ErrOutsideModule) {
+		t.Errorf("Duplicates: expected %v, got %v", dataloc.ErrOutsideModule, err)
+	}
+}
+
+func TestL_silentByDefault(t *testing.T) {
+	var buf bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&buf)
+
+	tests := []struct {
+		name string
+	}{
+		{"silent"},
+	}
+
+	for _, test := range tests {
+		if got := dataloc.L(test.name); got == "(unknown)" {
+			t.Errorf("expected %q to be found", test.name)
+		}
+		// an unsupported argument is reported to Logger only
+		dataloc.L(test.name + test.name)
+	}
+
+	if buf.Len() > 0 {
+		t.Errorf("expected no output, got %q", buf.String())
+	}
+}
+
+type caseName string
+
+func TestL_convertedName(t *testing.T) {
+	tests := []struct {
+		name caseName
+		line int
+	}{
+		{"converted", __line__()},
+	}
+
+	for _, test := range tests {
+		if got, expected := dataloc.L(string(caseName(test.name))), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+	}
+}
+
+func TestLoopLocation(t *testing.T) {
+	tests := []struct {
+		name string
+	}{
+		{"a"},
+	}
+
+	line := __line__() + 1
+	for _, test := range tests {
+		loc, err := dataloc.LoopLocation()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, expected := fmt.Sprintf("%s:%d", loc, loc.Column), fmt.Sprintf("%s:%d:2", file, line); got != expected {
+			t.Errorf("%s: expected %q, got %q", test.name, expected, got)
+		}
+	}
+
+	if _, err := dataloc.LoopLocation(); err == nil {
+		t.Error("expected an error outside of a loop")
+	}
+}
+
+func TestLErr(t *testing.T) {
+	tests := []struct {
+		name string
+		line int
+	}{
+		{"found", __line__()},
+	}
+
+	for _, test := range tests {
+		got, err := dataloc.LErr(test.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+	}
+
+	got, err := dataloc.LErr("missing")
+	if !errors.Is(err, dataloc.ErrNotFound) {
+		t.Errorf("expected %v, got %v", dataloc.ErrNotFound, err)
+	}
+	if expected := "(unknown)"; got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+
+	*dataloc.ReadFile = func(string) ([]byte, error) { return nil, os.ErrNotExist }
+	defer func() { *dataloc.ReadFile = os.ReadFile }()
+	dataloc.Reset()
+	defer dataloc.Reset()
+
+	for _, test := range tests {
+		if _, err := dataloc.LErr(test.name); err == nil || errors.Is(err, dataloc.ErrNotFound) {
+			t.Errorf("expected the error reading the source, got %v", err)
+		}
+	}
+}
+
+func TestLSkip(t *testing.T) {
+	tests := []struct {
+		name string
+		line int
+	}{
+		{"skipped", __line__()},
+	}
+
+	for _, test := range tests {
+		if got, expected := dataloc.LSkip(2, test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+		if got, expected := dataloc.LSkip(2, test.name), dataloc.L(test.name); got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+	}
+}
+
+func TestL_rawStringName(t *testing.T) {
+	tests := []struct {
+		name string
+		line int
+	}{
+		{name: `GET /users/{id}`, line: __line__()},
+		{`POST /users/{id}/"posts"`, __line__()},
+	}
+
+	for _, test := range tests {
+		if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("%s: expected %q, got %q", test.name, expected, got)
+		}
+	}
+}
+
+func TestL_namesSlice(t *testing.T) {
+	lines := map[string]int{}
+	names := []string{
+		"add",
+		"remove",
+	}
+	lines["add"], lines["remove"] = __line__()-3, __line__()-2
+
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			switch name {
+			case "add":
+			case "remove":
+			}
+			if got, expected := dataloc.L(name), fmt.Sprintf("%s:%d", file, lines[name]); got != expected {
+				t.Errorf("expected %q, got %q", expected, got)
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		name string
+		line int
+	}{
+		{"found", __line__()},
+	}
+
+	for _, test := range tests {
+		loc, err := dataloc.Find(test.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := dataloc.Location{File: file, Line: test.line, Column: 3}
+		if loc != expected {
+			t.Errorf("expected %+v, got %+v", expected, loc)
+		}
+		if got, expected := loc.String(), dataloc.L(test.name); got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+	}
+
+	if _, err := dataloc.Find("missing"); !errors.Is(err, dataloc.ErrNotFound) {
+		t.Errorf("expected %v, got %v", dataloc.ErrNotFound, err)
+	}
+}
+
+func TestPreload(t *testing.T) {
+	dataloc.Reset()
+	fixture := filepath.Join("testdata", "resolve.go")
+	if err := dataloc.Preload(fixture); err != nil {
+		t.Fatal(err)
+	}
+	if err := dataloc.Preload(); err != nil {
+		t.Fatal(err)
+	}
+	parses := dataloc.ParseCount()
+
+	if _, err := dataloc.ResolveFile(fixture); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		line int
+	}{
+		{"preloaded", __line__()},
+	}
+
+	for _, test := range tests {
+		if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+	}
+
+	if got := dataloc.ParseCount(); got != parses {
+		t.Errorf("expected no file parsed after Preload, got %d", got-parses)
+	}
+}
+
+func TestL_rawStringKey(t *testing.T) {
+	tests := map[string]int{
+		`say "hi"`:    __line__(),
+		`C:\temp\x`:   __line__(),
+		"say \"hi\"!": __line__(),
+	}
+
+	for name, line := range tests {
+		if got, expected := dataloc.L(name), fmt.Sprintf("%s:%d", file, line); got != expected {
+			t.Errorf("%s: expected %q, got %q", name, expected, got)
+		}
+	}
+}
+
+func TestResolveFile_modified(t *testing.T) {
+	src, err := os.ReadFile(filepath.Join("testdata", "resolve.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixture := filepath.Join(t.TempDir(), "resolve.go")
+	if err := os.WriteFile(fixture, src, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := dataloc.ResolveFile(fixture); err != nil {
+		t.Fatal(err)
+	}
+	parses := dataloc.ParseCount()
+	if _, err := dataloc.ResolveFile(fixture); err != nil {
+		t.Fatal(err)
+	}
+	if got := dataloc.ParseCount(); got != parses {
+		t.Errorf("expected the file not to be parsed again, got %d parses", got-parses)
+	}
+
+	// the test cases move down a line
+	if err := os.WriteFile(fixture, append([]byte("\n"), src...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Second)
+	if err := os.Chtimes(fixture, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	resolved, err := dataloc.ResolveFile(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := resolved[22]; r.Name != "b" || r.Location.Line != 15 {
+		t.Errorf("expected b at line 15, got %+v", r)
+	}
+}
+
+func TestResolveFile_siblingAdded(t *testing.T) {
+	dir := t.TempDir()
+	fixture := filepath.Join(dir, "a_test.go")
+	src := `package fixture
+
+import "github.com/client9/go-testutil/dataloc"
+
+func TestAdded(t *testing.T) {
+	for _, test := range added {
+		t.Log(dataloc.L(test.name))
+	}
+	t.Log(dataloc.L("x"))
+}
+`
+	if err := os.WriteFile(fixture, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	resolved, err := dataloc.ResolveFile(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := resolved[9]; r.Location != (dataloc.Location{}) {
+		t.Errorf("expected x not to be found without its table, got %+v", r)
+	}
+
+	// the table is added in a new file of the package
+	sibling := filepath.Join(dir, "b_test.go")
+	if err := os.WriteFile(sibling, []byte("package fixture\n\nvar added = []struct{ name string }{\n\t{\"x\"},\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Second)
+	if err := os.Chtimes(dir, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	resolved, err = dataloc.ResolveFile(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := resolved[9]; filepath.Base(r.Location.File) != "b_test.go" || r.Location.Line != 4 {
+		t.Errorf("expected x at b_test.go:4, got %+v", r)
+	}
+}
+
+func pairA() {}
+
+func TestL_namedFuncPairs(t *testing.T) {
+	pairB := func() {}
+	tests := []struct {
+		name string
+		fn   func()
+		line int
+	}{
+		{"a", pairA, __line__()},
+		{"b", pairB, __line__()},
+		{"c", func() {}, __line__()},
+		{fn: pairA, name: "d", line: __line__()},
+	}
+
+	for _, test := range tests {
+		test.fn()
+		if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("%s: expected %q, got %q", test.name, expected, got)
+		}
+	}
+}
+
+func TestLocateInBlob(t *testing.T) {
+	fixture := filepath.Join("testdata", "resolve.go")
+	src, err := os.ReadFile(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a past revision, three lines shorter than the file on disk
+	blob := bytes.Replace(src, []byte("\"testing\"\n\n"), []byte("\"testing\"\n"), 1)
+	blob = bytes.Replace(blob, []byte("{\n\t\tname string\n\t}{"), []byte("{ name string }{"), 1)
+
+	loc, err := dataloc.LocateInBlob(blob, fixture, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := (dataloc.Location{File: fixture, Line: 11, Column: 3}); loc != expected {
+		t.Errorf("expected %+v, got %+v", expected, loc)
+	}
+
+	if _, err := dataloc.LocateInBlob(blob, fixture, "missing"); !errors.Is(err, dataloc.ErrNotFound) {
+		t.Errorf("expected %v, got %v", dataloc.ErrNotFound, err)
+	}
+}
+
+type packageCase struct {
+	name string
+	line int
+}
+
+var packageCases = []packageCase{
+	{name: "keyed", line: __line__()},
+	{"unkeyed", __line__()},
+}
+
+var (
+	packageCasesByName = map[string]packageCase{
+		"entry": {line: __line__()},
+	}
+)
+
+func TestL_packageLevelTable(t *testing.T) {
+	for _, test := range packageCases {
+		if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("%s: expected %q, got %q", test.name, expected, got)
+		}
+	}
+
+	for name, test := range packageCasesByName {
+		if got, expected := dataloc.L(name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("%s: expected %q, got %q", name, expected, got)
+		}
+	}
+}
+
+var basePackageCases = []packageCase{
+	{name: "aliased", line: __line__()},
+}
+
+var aliasedPackageCases = basePackageCases
+
+func TestL_packageLevelAlias(t *testing.T) {
+	for _, test := range aliasedPackageCases {
+		if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("%s: expected %q, got %q", test.name, expected, got)
+		}
+	}
+
+	// not valid Go, which the lookup must still survive
+	resolved, err := dataloc.ResolveFile(filepath.Join("testdata", "cycle.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := resolved[11]; r != (dataloc.Resolved{Name: "a"}) {
+		t.Errorf("expected an unresolved name, got %+v", r)
+	}
+}
+
+var otherPackageCases, lastPackageCases = []packageCase{
+	{"not last", __line__()},
+}, []packageCase{
+	{"last of spec", __line__()},
+}
+
+var (
+	groupedOtherCases, groupedLastCases = []packageCase{
+		{"not last in group", __line__()},
+	}, []packageCase{
+		{"last of grouped spec", __line__()},
+	}
+)
+
+func TestL_lastVarOfSpec(t *testing.T) {
+	for _, test := range lastPackageCases {
+		if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("%s: expected %q, got %q", test.name, expected, got)
+		}
+	}
+	for _, test := range groupedLastCases {
+		if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("%s: expected %q, got %q", test.name, expected, got)
+		}
+	}
+	for _, test := range groupedOtherCases {
+		if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("%s: expected %q, got %q", test.name, expected, got)
+		}
+	}
+	for _, test := range otherPackageCases {
+		if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("%s: expected %q, got %q", test.name, expected, got)
+		}
+	}
+}
+
+func TestL_tableInAnotherFile(t *testing.T) {
+	for _, test := range sharedCases {
+		if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", "shared_test.go", test.line); got != expected {
+			t.Errorf("%s: expected %q, got %q", test.name, expected, got)
+		}
+	}
+}
+
+func TestL_funcInAnotherFile(t *testing.T) {
+	for _, test := range sharedCases {
+		if got, expected := dataloc.L(swapName(test.name)), "(unknown)"; got != expected {
+			t.Errorf("%s: expected %q, got %q", test.name, expected, got)
+		}
+	}
+}
+
+func TestMatchFieldFold(t *testing.T) {
+	tests := map[platform]int{
+		{"linux", "amd64"}:                    __line__(),
+		platform{os: "darwin", arch: "arm64"}: __line__(),
+	}
+
+	// as given by external metadata
+	pairsOf := func(p platform) map[string]string {
+		return map[string]string{"OS": p.os, "Arch": p.arch}
+	}
+
+	for p := range tests {
+		if _, err := dataloc.LocateByKeyFields(pairsOf(p)); !errors.Is(err, dataloc.ErrNotFound) {
+			t.Errorf("%v: expected %v, got %v", p, dataloc.ErrNotFound, err)
+		}
+	}
+
+	dataloc.MatchFieldFold = true
+	t.Cleanup(func() { dataloc.MatchFieldFold = false })
+
+	for p, line := range tests {
+		loc, err := dataloc.LocateByKeyFields(pairsOf(p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, expected := loc.String(), fmt.Sprintf("%s:%d", file, line); got != expected {
+			t.Errorf("%v: expected %q, got %q", p, expected, got)
+		}
+	}
+}
+
+func TestHas(t *testing.T) {
+	tests := []struct {
+		name string
+	}{
+		{"present"},
+	}
+
+	for _, test := range tests {
+		if !dataloc.Has(test.name) {
+			t.Errorf("expected %q to be found", test.name)
+		}
+	}
+
+	if dataloc.Has("absent") {
+		t.Errorf("expected %q not to be found", "absent")
+	}
+}
+
+type pointerCase struct {
+	name string
+	line int
+}
+
+func TestL_pointerCases(t *testing.T) {
+	tests := []*pointerCase{
+		{name: "keyed", line: __line__()},
+		{"unkeyed", __line__()},
+		&pointerCase{"address", __line__()},
+		&pointerCase{name: "keyed address", line: __line__()},
+	}
+
+	for _, test := range tests {
+		if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("%s: expected %q, got %q", test.name, expected, got)
+		}
+	}
+}
+
+func TestLInt(t *testing.T) {
+	tests := map[int]int{
+		1:   __line__(),
+		0x2: __line__(),
+		300: __line__(),
+	}
+
+	for id, line := range tests {
+		if got, expected := dataloc.LInt(id), fmt.Sprintf("%s:%d", file, line); got != expected {
+			t.Errorf("%d: expected %q, got %q", id, expected, got)
+		}
+	}
+}
+
+func keyOf(name string) string {
+	return "key_" + name
+}
+
+func TestL_computedKey(t *testing.T) {
+	tests := map[string]int{
+		keyOf("a"):         __line__(),
+		keyOf(keyOf("b")):  __line__(),
+		("c" + keyOf("d")): __line__(),
+	}
+
+	for name, line := range tests {
+		if got, expected := dataloc.L(name), fmt.Sprintf("%s:%d", file, line); got != expected {
+			t.Errorf("%s: expected %q, got %q", name, expected, got)
+		}
+	}
+}
+
+func TestLocateRunArg(t *testing.T) {
+	tests := []struct {
+		name string
+		line int
+	}{
+		{"plain", __line__()},
+		{"with spaces", __line__()},
+		{"plain", __line__()},
+		{"group/case", __line__()},
+		{"case", __line__()},
+		{"rerun#01", __line__()},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			loc, err := dataloc.LocateRunArg(t)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// a rerun of a name is located at its first test case
+			line := test.line
+			if i == 2 {
+				line = tests[0].line
+			}
+			if got, expected := loc.String(), fmt.Sprintf("%s:%d", file, line); got != expected {
+				t.Errorf("expected %q, got %q", expected, got)
+			}
+		})
+	}
+}
+
+func TestL_concatenatedName(t *testing.T) {
+	prefix := "user_"
+	tests := []struct {
+		name string
+		line int
+	}{
+		{name: "user_" + "create", line: __line__()},
+		{"user_" + "delete" + "_all", __line__()},
+		{prefix + "update", 0},
+	}
+
+	for _, test := range tests {
+		expected := fmt.Sprintf("%s:%d", file, test.line)
+		if test.line == 0 {
+			// a variable is not guessed
+			expected = "(unknown)"
+		}
+		if got := dataloc.L(test.name); got != expected {
+			t.Errorf("%s: expected %q, got %q", test.name, expected, got)
+		}
+	}
+}
+
+const caseSingle = "single"
+
+const (
+	casePrefix  = "grouped_"
+	caseGrouped = casePrefix + "const"
+)
+
+func TestL_constName(t *testing.T) {
+	tests := []struct {
+		name string
+		line int
+	}{
+		{name: caseSingle, line: __line__()},
+		{caseGrouped, __line__()},
+	}
+
+	for _, test := range tests {
+		if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("%s: expected %q, got %q", test.name, expected, got)
+		}
+	}
+}
+
+func TestL_configMapCases(t *testing.T) {
+	type testcase struct {
+		line int
+	}
+	type testConfig struct {
+		timeout int
+		cases   map[string]testcase
+	}
+
+	cfg := testConfig{
+		timeout: 1,
+		cases: map[string]testcase{
+			"keyed": {line: __line__()},
+		},
+	}
+	positional := testConfig{1, map[string]testcase{
+		"positional": {__line__()},
+	}}
+
+	for name := range cfg.cases {
+		if got, expected := dataloc.L(name), fmt.Sprintf("%s:%d", file, cfg.cases[name].line); got != expected {
+			t.Errorf("%s: expected %q, got %q", name, expected, got)
+		}
+	}
+	for name := range positional.cases {
+		if got, expected := dataloc.L(name), fmt.Sprintf("%s:%d", file, positional.cases[name].line); got != expected {
+			t.Errorf("%s: expected %q, got %q", name, expected, got)
+		}
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	others := []struct {
+		name string
+		line int
+	}{
+		{"candidate shared", __line__()},
+		{"candidate other", __line__()},
+	}
+	tests := []struct {
+		name string
+		line int
+	}{
+		{"candidate shared", __line__()},
+		{"candidate unique", __line__()},
+	}
+
+	for _, test := range tests {
+		locs, err := dataloc.Candidates(test.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, loc := range locs {
+			got = append(got, loc.String())
+		}
+		expected := []string{fmt.Sprintf("%s:%d", file, test.line)}
+		if test.name == "candidate shared" {
+			expected = append([]string{fmt.Sprintf("%s:%d", file, others[0].line)}, expected...)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, expected, got)
+		}
+	}
+}
+
+func TestResolveFile_iterators(t *testing.T) {
+	resolved, err := dataloc.ResolveFile(filepath.Join("testdata", "iter.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[int]string{41: "a@15", 48: "b@16", 60: "b@16"}
+	for line, want := range expected {
+		r := resolved[line]
+		if got := fmt.Sprintf("%s@%d", r.Name, r.Location.Line); got != want {
+			t.Errorf("line %d: expected %s, got %s", line, want, got)
+		}
+	}
+}
+
+type caseGroup int
+
+const (
+	groupAdmin caseGroup = iota
+	groupGuest
+)
+
+func (g caseGroup) String() string {
+	return [...]string{"admin", "guest"}[g]
+}
+
+func TestFindMatch_enumJoin(t *testing.T) {
+	tests := []struct {
+		group caseGroup
+		name  string
+		line  int
+	}{
+		{group: groupAdmin, name: "login", line: __line__()},
+		{groupGuest, "login", __line__()},
+	}
+
+	for _, test := range tests {
+		m, err := dataloc.FindMatch(test.group.String() + ":" + test.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, expected := m.String(), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("%s:%s: expected %q, got %q", test.group, test.name, expected, got)
+		}
+		if m.Exact {
+			t.Errorf("%s:%s: expected an inexact match", test.group, test.name)
+		}
+	}
+
+	for _, test := range tests {
+		m, err := dataloc.FindMatch(test.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !m.Exact {
+			t.Errorf("%s: expected an exact match", test.name)
+		}
+	}
+}
+
+func TestLocateMapIndex(t *testing.T) {
+	tests := map[string]struct {
+		index int
+		line  int
+	}{
+		"map index c": {2, __line__()},
+		"map index a": {0, __line__()},
+		"map index b": {1, __line__()},
+	}
+
+	for name, test := range tests {
+		loc, err := dataloc.LocateMapIndex(test.index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, expected := loc.String(), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("%s: expected %q, got %q", name, expected, got)
+		}
+		if _, err := dataloc.LocateMapIndex(len(tests)); !errors.Is(err, dataloc.ErrNotFound) {
+			t.Errorf("expected ErrNotFound past the last key, got %v", err)
+		}
+	}
+}
+
+func TestResolveFile_dotImport(t *testing.T) {
+	fixture := filepath.Join("testdata", "dotimport.go")
+	resolved, err := dataloc.ResolveFile(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the local L shadowing the dot-imported one at line 24 is not a call site
+	expected := map[int]dataloc.Resolved{
+		18: {},
+		21: {Name: "b", Location: dataloc.Location{File: fixture, Line: 14, Column: 3}},
+	}
+	if len(resolved) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, resolved)
+	}
+	for line, r := range expected {
+		if got := resolved[line]; got != r {
+			t.Errorf("line %d: expected %+v, got %+v", line, r, got)
+		}
+	}
+}
+
+func TestFind_strictNames(t *testing.T) {
+	tests := []struct {
+		name string
+		line int
+	}{
+		{"strict duplicate", __line__()},
+		{"strict unique", __line__()},
+		{"strict duplicate", __line__()},
+	}
+
+	dataloc.StrictNames = true
+	defer func() { dataloc.StrictNames = false }()
+
+	for _, test := range tests {
+		loc, err := dataloc.Find(test.name)
+		if test.name == "strict duplicate" {
+			if !errors.Is(err, dataloc.ErrAmbiguous) {
+				t.Fatalf("%s: expected ErrAmbiguous, got %v", test.name, err)
+			}
+			for _, other := range tests {
+				if other.name == test.name && !strings.Contains(err.Error(), fmt.Sprintf("%s:%d:", file, other.line)) {
+					t.Errorf("%s: expected line %d in %q", test.name, other.line, err)
+				}
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, expected := loc.String(), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("%s: expected %q, got %q", test.name, expected, got)
+		}
+	}
+}
+
+func TestSARIFResult(t *testing.T) {
+	tests := []struct {
+		name string
+		line int
+	}{
+		{"sarif", __line__()},
+	}
+
+	for _, test := range tests {
+		b, err := dataloc.SARIFResult(test.name, "test-failure", "expected 1, got 2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var result struct {
+			RuleID  string `json:"ruleId"`
+			Message struct {
+				Text string `json:"text"`
+			} `json:"message"`
+			Locations []struct {
+				PhysicalLocation struct {
+					ArtifactLocation struct {
+						URI string `json:"uri"`
+					} `json:"artifactLocation"`
+					Region struct {
+						StartLine   int `json:"startLine"`
+						StartColumn int `json:"startColumn"`
+					} `json:"region"`
+				} `json:"physicalLocation"`
+			} `json:"locations"`
+		}
+		if err := json.Unmarshal(b, &result); err != nil {
+			t.Fatal(err)
+		}
+		if result.RuleID != "test-failure" || result.Message.Text != "expected 1, got 2" || len(result.Locations) != 1 {
+			t.Fatalf("unexpected result %s", b)
+		}
+		loc := result.Locations[0].PhysicalLocation
+		if loc.ArtifactLocation.URI != filepath.ToSlash(file) || loc.Region.StartLine != test.line || loc.Region.StartColumn != 3 {
+			t.Errorf("unexpected location in %s", b)
+		}
+	}
+
+	if _, err := dataloc.SARIFResult("missing", "test-failure", ""); !errors.Is(err, dataloc.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+type embeddedBase struct {
+	name string
+}
+
+type embeddingCase struct {
+	line int
+	embeddedBase
+}
+
+func TestL_embeddedName(t *testing.T) {
+	tests := []embeddingCase{
+		{__line__(), embeddedBase{"embedded positional"}},
+		{line: __line__(), embeddedBase: embeddedBase{name: "embedded keyed"}},
+	}
+
+	for _, test := range tests {
+		if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("%s: expected %q, got %q", test.name, expected, got)
+		}
+	}
+}
+
+func TestL_anonymousStructPositional(t *testing.T) {
+	tests := []struct {
+		name string
+		in   int
+		line int
+	}{
+		{"anonymous foo", 1, __line__()},
+		{"anonymous bar", 2, __line__()},
+	}
+	grouped := []struct {
+		in, name string
+		line     int
+	}{
+		{"x", "anonymous grouped", __line__()},
+	}
+
+	for _, test := range tests {
+		if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("%s: expected %q, got %q", test.name, expected, got)
+		}
+	}
+	// the second of the fields declared together is the second value
+	for _, test := range grouped {
+		if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("%s: expected %q, got %q", test.name, expected, got)
+		}
+	}
+}
+
+type caseMeta struct {
+	owner string
+}
+
+type middleEmbeddingCase struct {
+	in string
+	caseMeta
+	name string
+	line int
+}
+
+func TestL_embeddedInTheMiddle(t *testing.T) {
+	tests := []middleEmbeddingCase{
+		{"x", caseMeta{"middle owner"}, "middle name", __line__()},
+		{"middle name", caseMeta{"y"}, "other name", __line__()},
+	}
+
+	for _, test := range tests {
+		if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("%s: expected %q, got %q", test.name, expected, got)
+		}
+	}
+}
+
+func TestLocateFromFrame(t *testing.T) {
+	fixture := filepath.Join("testdata", "resolve.go")
+	fr := runtime.Frame{File: fixture, Line: 18}
+
+	loc, err := dataloc.LocateFromFrame(fr, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := (dataloc.Location{File: fixture, Line: 14, Column: 3}); loc != expected {
+		t.Errorf("expected %+v, got %+v", expected, loc)
+	}
+
+	if _, err := dataloc.LocateFromFrame(fr, "missing"); !errors.Is(err, dataloc.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+// frameOf captures the frame of its caller, as a logging library would, with
+// the name being logged.
+func frameOf(name string) runtime.Frame {
+	pc, file, line, _ := runtime.Caller(1)
+	return runtime.Frame{PC: pc, File: file, Line: line}
+}
+
+func TestLocateFromFrame_capturedFrame(t *testing.T) {
+	tests := []struct {
+		name string
+		line int
+	}{
+		{"framed", __line__()},
+	}
+
+	for _, test := range tests {
+		fr := frameOf(test.name)
+		loc, err := dataloc.LocateFromFrame(fr, test.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, expected := loc.String(), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+	}
+}
+
+func TestLField(t *testing.T) {
+	tests := []struct {
+		desc string
+		line int
+	}{
+		{desc: "field keyed", line: __line__()},
+		{"field positional", __line__()},
+	}
+	byKey := map[int]struct {
+		desc string
+		line int
+	}{
+		1: {"field map value", __line__()},
+	}
+
+	for _, test := range tests {
+		n := test.desc
+		if got, expected := dataloc.LField("desc", n), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("%s: expected %q, got %q", n, expected, got)
+		}
+	}
+	for _, test := range byKey {
+		n := test.desc
+		if got, expected := dataloc.LField("desc", n), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("%s: expected %q, got %q", n, expected, got)
+		}
+	}
+	for range tests {
+		if got := dataloc.LField("desc", "missing"); got != "(unknown)" {
+			t.Errorf("expected (unknown), got %q", got)
+		}
+	}
+}
+
+func TestL_nameVariable(t *testing.T) {
+	tests := []struct {
+		name string
+		line int
+	}{
+		{name: "variable keyed", line: __line__()},
+		{"variable positional", __line__()},
+	}
+
+	for _, test := range tests {
+		n := test.name
+		if got, expected := dataloc.L(n), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("%s: expected %q, got %q", n, expected, got)
+		}
+	}
+}
+
+func TestIndexFile_examples(t *testing.T) {
+	cases, err := dataloc.IndexFile("example_test.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, c := range cases {
+		got = append(got, fmt.Sprintf("%s@%d", c.Name, c.Location.Line))
+	}
+	if expected := "[100+200@15 1+1@21 empty@46 word@47]"; fmt.Sprint(got) != expected {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestL_arrayTable(t *testing.T) {
+	fixed := [2]struct {
+		name string
+		line int
+	}{
+		{name: "fixed keyed", line: __line__()},
+		{"fixed positional", __line__()},
+	}
+	elided := [...]struct {
+		name string
+		line int
+	}{
+		{name: "elided keyed", line: __line__()},
+		{"elided positional", __line__()},
+	}
+	names := [...]string{"elided name"}
+
+	for _, test := range fixed {
+		if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("%s: expected %q, got %q", test.name, expected, got)
+		}
+	}
+	for _, test := range &elided {
+		if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("%s: expected %q, got %q", test.name, expected, got)
+		}
+	}
+	for _, name := range names {
+		if got, expected := dataloc.L(name), fmt.Sprintf("%s:%d", file, elided[1].line+2); got != expected {
+			t.Errorf("%s: expected %q, got %q", name, expected, got)
+		}
+	}
+}
+
+func TestL_indexedField(t *testing.T) {
+	byKey := map[string]struct {
+		name string
+		line int
+	}{
+		"a": {name: "indexed keyed", line: __line__()},
+		"b": {"indexed positional", __line__()},
+	}
+	bySlice := []struct {
+		name string
+		line int
+	}{
+		{"indexed element", __line__()},
+	}
+
+	for key := range byKey {
+		if got, expected := dataloc.L(byKey[key].name), fmt.Sprintf("%s:%d", file, byKey[key].line); got != expected {
+			t.Errorf("%s: expected %q, got %q", key, expected, got)
+		}
+	}
+	for i := range bySlice {
+		if got, expected := dataloc.L(bySlice[i].name), fmt.Sprintf("%s:%d", file, bySlice[i].line); got != expected {
+			t.Errorf("%d: expected %q, got %q", i, expected, got)
+		}
+	}
+}
+
+func TestExplain(t *testing.T) {
+	tests := []struct {
+		name string
+		line int
+	}{
+		{"explained", __line__()},
+	}
+
+	for _, test := range tests {
+		got, err := dataloc.Explain(test.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, expected := range []string{
+			"call: dataloc.Explain(test.name)",
+			"variable: test ranges over tests",
+			"table: slice []struct",
+			"field: name",
+			fmt.Sprintf(`match: "explained" at %s:%d:3`, file, test.line),
+		} {
+			if !strings.Contains(got, expected) {
+				t.Errorf("expected %q in:\n%s", expected, got)
+			}
+		}
+
+		test.name = "missing"
+		got, err = dataloc.Explain(test.name)
+		if !errors.Is(err, dataloc.ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+		if !strings.Contains(got, "no match among 1 test cases") {
+			t.Errorf("expected no match in:\n%s", got)
+		}
+	}
+}
+
+func helperReturnedCases() []helperCase {
+	return []helperCase{
+		{name: "returned keyed", line: __line__()},
+		{"returned positional", __line__()},
+	}
+}
+
+func TestL_rangeOverHelperCall(t *testing.T) {
+	for _, test := range helperReturnedCases() {
+		if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("%s: expected %q, got %q", test.name, expected, got)
+		}
+	}
+}
+
+type caseSuite struct {
+	cases []helperCase
+}
+
+func (s *caseSuite) SetupTest() {
+	s.cases = []helperCase{
+		{name: "suite keyed", line: __line__()},
+		{"suite positional", __line__()},
+	}
+}
+
+func (s *caseSuite) TestCases(t *testing.T) {
+	for _, test := range s.cases {
+		if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("%s: expected %q, got %q", test.name, expected, got)
+		}
+	}
+}
+
+func TestL_suiteField(t *testing.T) {
+	s := &caseSuite{}
+	s.SetupTest()
+	s.TestCases(t)
+}
+
+var whereFinder = dataloc.New(dataloc.WithFuncName("whereCase"))
+
+func whereCase(name string) string {
+	loc, err := whereFinder.Find(3, name)
+	if err != nil {
+		return err.Error()
+	}
+	return loc.String()
+}
+
+func firstHelperCase(cases []helperCase) helperCase {
+	for _, c := range cases {
+		return c
+	}
+	return helperCase{}
+}
+
+func TestFinder(t *testing.T) {
+	tests := []helperCase{
+		{name: "finder keyed", line: __line__()},
+		{"finder positional", __line__()},
+	}
+
+	var buf bytes.Buffer
+	finder := dataloc.New(dataloc.WithLogger(log.New(&buf, "", 0)))
+
+	for _, test := range tests {
+		loc, err := finder.Find(2, test.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, expected := loc.String(), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("%s: expected %q, got %q", test.name, expected, got)
+		}
+		if got, expected := whereCase(test.name), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("%s: expected %q, got %q", test.name, expected, got)
+		}
+	}
+
+	if _, err := finder.Find(2, firstHelperCase(tests).name); !errors.Is(err, dataloc.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "unsupported form of argument: firstHelperCase(tests).name") {
+		t.Errorf("expected a diagnostic in the logger of the finder, got %q", buf.String())
+	}
+}
+
+func TestFinder_ownConfiguration(t *testing.T) {
+	tests := []helperCase{
+		{name: "Finder Config", line: __line__()},
+	}
+
+	// the package variables configure the functions of the package only
+	defer func(transform func(string) string) { dataloc.NameTransform = transform }(dataloc.NameTransform)
+	dataloc.NameTransform = strings.ToUpper
+
+	root, err := filepath.Abs("..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	folding := dataloc.New(dataloc.WithNameTransform(strings.ToLower))
+	exact := dataloc.New()
+	parent := dataloc.New(dataloc.WithBasePath(root))
+
+	parses := dataloc.ParseCount()
+	for _, test := range tests {
+		loc, err := folding.Find(2, test.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, expected := loc.String(), fmt.Sprintf("%s:%d", file, test.line); got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+		if _, err := exact.Find(2, strings.ToLower(test.name)); !errors.Is(err, dataloc.ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+
+		loc, err = parent.Find(2, test.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, expected := loc.String(), fmt.Sprintf("%s:%d", filepath.Join("dataloc", file), test.line); got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+	}
+	if n := dataloc.ParseCount() - parses; n != 0 {
+		t.Errorf("expected the finders to use their own cache, got %d files parsed by the package", n)
+	}
+}
+
+func TestFinder_loggerOfSiblings(t *testing.T) {
+	tests := []helperCase{
+		{name: "sibling errors", line: __line__()},
+	}
+
+	defer func(f func(string) ([]byte, error)) { *dataloc.ReadFile = f }(*dataloc.ReadFile)
+	*dataloc.ReadFile = func(name string) ([]byte, error) {
+		if filepath.Base(name) == "shared_test.go" {
+			return nil, os.ErrPermission
+		}
+		return os.ReadFile(name)
+	}
+
+	var global bytes.Buffer
+	dataloc.SetLogger(log.New(&global, "", 0))
+	defer dataloc.SetLogger(nil)
+
+	var buf bytes.Buffer
+	finder := dataloc.New(dataloc.WithLogger(log.New(&buf, "", 0)))
+
+	for _, test := range tests {
+		if _, err := finder.Find(2, test.name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !strings.Contains(buf.String(), "could not read") || !strings.Contains(buf.String(), "shared_test.go") {
+		t.Errorf("expected the error reading shared_test.go in the logger of the finder, got %q", buf.String())
+	}
+	if global.Len() > 0 {
+		t.Errorf("expected nothing in the package Logger, got %q", global.String())
+	}
+}
+
+func TestFinder_skipTooDeep(t *testing.T) {
+	tests := []struct {
+		name string
+	}{
+		{"too deep"},
+	}
+
+	for _, test := range tests {
+		_, err := dataloc.New().Find(1000, test.name)
+		if err == nil || !strings.Contains(err.Error(), "could not recover caller at skip") {
+			t.Errorf("expected an error recovering the caller, got %v", err)
+		}
+		if got := dataloc.LSkip(1000, test.name); got != "" {
+			t.Errorf("expected an empty location, got %q", got)
+		}
+	}
+}
+
+func TestL_relFails(t *testing.T) {
+	tests := []struct {
+		name string
+		line int
+	}{
+		{"absolute", __line__()},
+	}
+
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// an absolute path cannot be made relative to a relative one, as it
+	// cannot to a path on another volume on Windows
+	defer func(s string) { dataloc.BasePath = s }(dataloc.BasePath)
+	dataloc.BasePath = filepath.Join("other", "volume")
+
+	for _, test := range tests {
+		if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", abs, test.line); got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+	}
+}
+
+func TestUseModuleRoot(t *testing.T) {
+	tests := []struct {
+		name string
+		line int
+	}{
+		{"module relative", __line__()},
+	}
+
+	defer func(s string) { dataloc.BasePath = s }(dataloc.BasePath)
+	if err := dataloc.UseModuleRoot(); err != nil {
+		t.Fatal(err)
+	}
+
+	root, err := dataloc.ModuleRoot(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "go.mod")); err != nil {
+		t.Fatal(err)
+	}
+	if dataloc.BasePath != root {
+		t.Errorf("expected BasePath %q, got %q", root, dataloc.BasePath)
+	}
+
+	for _, test := range tests {
+		if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", filepath.Join("dataloc", file), test.line); got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+	}
+}
+
+// recordingTB is a testing.TB recording its cleanups and logs.
+type recordingTB struct {
+	testing.TB
+	failed   bool
+	cleanups []func()
+	logs     []string
+}
+
+func (tb *recordingTB) Helper()          {}
+func (tb *recordingTB) Failed() bool     { return tb.failed }
+func (tb *recordingTB) Cleanup(f func()) { tb.cleanups = append(tb.cleanups, f) }
+func (tb *recordingTB) Logf(format string, args ...interface{}) {
+	tb.logs = append(tb.logs, fmt.Sprintf(format, args...))
+}
+
+func TestLocate(t *testing.T) {
+	tests := []struct {
+		name string
+		line int
+	}{
+		{"located", __line__()},
+	}
+
+	for _, failed := range []bool{false, true} {
+		for _, test := range tests {
+			tb := &recordingTB{TB: t, failed: failed}
+			dataloc.Locate(tb, test.name)
+			for _, f := range tb.cleanups {
+				f()
+			}
+
+			var expected []string
+			if failed {
+				expected = []string{fmt.Sprintf("case %q defined at %s:%d", test.name, file, test.line)}
+			}
+			if fmt.Sprint(tb.logs) != fmt.Sprint(expected) {
+				t.Errorf("failed=%v: expected %q, got %q", failed, expected, tb.logs)
+			}
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package dataloc_test
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/client9/go-testutil/dataloc"
+)
+
+func TestL_dotImport(t *testing.T) {
+	tests := []struct {
+		name string
+		line int
+	}{
+		{"dot-imported", __line__()},
+	}
+
+	for _, test := range tests {
+		if got, expected := L(test.name), fmt.Sprintf("%s:%d", "dot_test.go", test.line); got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package dataloc
+
+import "fmt"
+
+// Duplicates returns the names given to more than one test case, each mapped
+// to the locations of all the test cases sharing it.
+// The tables examined are the ones looked up by the dataloc.L() calls in the
+// function calling Duplicates, eg.
+//
+//	for _, testcase := range testcases {
+//		t.Run(testcase.name, func(t *testing.T) {
+//			t.Log(dataloc.L(testcase.name))
+//		})
+//	}
+//	dups, err := dataloc.Duplicates()
+//
+// Only test cases named by string literals are taken into account.
+func Duplicates() (map[string][]Location, error) {
+	c, err := callerAt(2)
+	if err != nil {
+		return nil, err
+	}
+	fset, ix := c.fset, c.ix
+
+	fn := enclosingFunc(fset, c.file, c.line)
+	if fn == nil {
+		return nil, fmt.Errorf("dataloc: no function found at %s:%d", fset.File(c.file.Pos()).Name(), c.line)
+	}
+
+	dups := map[string][]Location{}
+	for _, l := range ix.lookupsIn(fn, importName(c.file)) {
+		byName := map[string][]Location{}
+		var names []string
+		for _, item := range ix.items(l) {
+			name, ok := ix.stringValue(item.name)
+			if !ok {
+				continue
+			}
+			if byName[name] == nil {
+				names = append(names, name)
+			}
+			byName[name] = append(byName[name], positionToLocation(fset.Position(item.node.Pos())))
+		}
+		for _, name := range names {
+			if len(byName[name]) > 1 {
+				dups[name] = append(dups[name], byName[name]...)
+			}
+		}
+	}
+
+	return dups, nil
+}
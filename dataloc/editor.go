@@ -0,0 +1,53 @@
+package dataloc
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// EditorURLTemplate is the template of the URLs returned by EditorURL, in
+// which {file} is replaced by the absolute path of the test case with forward
+// slashes and a leading one, eg. /C:/src/foo_test.go on Windows, {line} by its
+// line and {col} by its column, eg. "idea://open?file={file}&line={line}".
+var EditorURLTemplate = "vscode://file{file}:{line}:{col}"
+
+// EditorURL returns a URL opening the test case identified by its name in an
+// editor, as given by EditorURLTemplate, eg.
+//
+//	vscode://file/home/user/src/foo/foo_test.go:12:3
+//
+// so that a failing test can print a link to it.
+// The same restrictions as L apply.
+func EditorURL(name string) (string, error) {
+	l, err := locate(name, 2)
+	if err != nil {
+		return "", err
+	}
+	if l == (Location{}) {
+		return "", fmt.Errorf("%w: %q", ErrNotFound, name)
+	}
+
+	file, err := absPath(l.File)
+	if err != nil {
+		return "", err
+	}
+
+	r := strings.NewReplacer(
+		"{file}", urlPath(file),
+		"{line}", strconv.Itoa(l.Line),
+		"{col}", strconv.Itoa(l.Column),
+	)
+	return r.Replace(EditorURLTemplate), nil
+}
+
+// urlPath returns file, an absolute path, with forward slashes and a leading
+// one, which a path starting with a volume name such as C: lacks.
+func urlPath(file string) string {
+	file = filepath.ToSlash(file)
+	if !strings.HasPrefix(file, "/") {
+		file = "/" + file
+	}
+	return file
+}
@@ -3,7 +3,7 @@ package dataloc_test
 import (
 	"fmt"
 
-	"github.com/motemen/go-testutil/dataloc"
+	"github.com/client9/go-testutil/dataloc"
 )
 
 func Example() {
@@ -36,3 +36,29 @@ func Example() {
 	// expected -1 but got 300, test case at example_test.go:15
 	// expected 99 but got 2, test case at example_test.go:21
 }
+
+func ExampleFind() {
+	testcases := []struct {
+		name string
+		in   string
+		want int
+	}{
+		{"empty", "", 0},
+		{"word", "word", 4},
+	}
+
+	for _, testcase := range testcases {
+		if got := len(testcase.in); got != testcase.want {
+			fmt.Printf("expected %d but got %d\n", testcase.want, got)
+		}
+		loc, err := dataloc.Find(testcase.name)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Printf("%s at %s:%d:%d\n", testcase.name, loc.File, loc.Line, loc.Column)
+	}
+
+	// Output:
+	// empty at example_test.go:46:3
+	// word at example_test.go:47:3
+}
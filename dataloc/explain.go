@@ -0,0 +1,109 @@
+package dataloc
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+)
+
+// Explain returns how the test case identified by its name is looked up, as
+// lines of text, eg.
+//
+//	caller: foo_test.go:42
+//	call: dataloc.Explain(testcase.name)
+//	variable: testcase ranges over testcases
+//	table: slice []testcase{…} at foo_test.go:12
+//	field: name
+//	match: "foo" at foo_test.go:13:3
+//
+// to be included in bug reports when a test case is not located as expected.
+// The explanation is returned along with ErrNotFound if no test case has the
+// name. The same restrictions as L apply.
+func Explain(name string) (string, error) {
+	c, err := callerAt(2)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "caller: %s:%d\n", c.fset.File(c.file.Pos()).Name(), c.line)
+
+	lookups := c.lookups()
+	if len(lookups) == 0 {
+		fmt.Fprintf(&b, "no supported call at the caller line\n")
+		return b.String(), fmt.Errorf("%w: %q", ErrNotFound, name)
+	}
+
+	for _, l := range lookups {
+		fmt.Fprintf(&b, "call: %s\n", types.ExprString(l.call))
+		for _, v := range c.ix.rangeVars(l.arg) {
+			fmt.Fprintf(&b, "variable: %s\n", v)
+		}
+		if l.table != nil {
+			fmt.Fprintf(&b, "table: %s %s at %s\n", tableKind(l.table), types.ExprString(l.table), positionToLocation(c.fset.Position(l.table.Pos())))
+		}
+		if l.key != "" {
+			fmt.Fprintf(&b, "field: %s\n", l.key)
+		}
+		if l.inexact {
+			fmt.Fprintf(&b, "matched by a heuristic\n")
+		}
+		if l.err != nil {
+			fmt.Fprintf(&b, "error: %v\n", l.err)
+			continue
+		}
+
+		items := c.ix.findItems(l, name)
+		if len(items) == 0 {
+			fmt.Fprintf(&b, "no match among %d test cases\n", len(c.ix.items(l)))
+			continue
+		}
+		for _, item := range items {
+			loc, err := c.location(item.node)
+			if err != nil {
+				return b.String(), err
+			}
+			fmt.Fprintf(&b, "match: %s at %s:%d:%d\n", types.ExprString(item.name), loc.File, loc.Line, loc.Column)
+		}
+		return b.String(), nil
+	}
+	return b.String(), fmt.Errorf("%w: %q", ErrNotFound, name)
+}
+
+// rangeVars describes the variables of range statements in arg, eg.
+// "testcase ranges over testcases".
+func (ix *index) rangeVars(arg ast.Expr) []string {
+	var vars []string
+	ast.Inspect(arg, func(n ast.Node) bool {
+		ident, ok := n.(*ast.Ident)
+		if !ok || ident.Obj == nil {
+			return true
+		}
+		if expr, ok := ix.objToRangeExprForKey[ident.Obj]; ok {
+			vars = append(vars, fmt.Sprintf("%s is the key of a range over %s", ident.Name, types.ExprString(expr)))
+		} else if expr, ok := ix.rangeExprForValue(ident); ok {
+			vars = append(vars, fmt.Sprintf("%s ranges over %s", ident.Name, types.ExprString(expr)))
+		} else if init, ok := ix.objToVarInit[ident.Obj]; ok {
+			vars = append(vars, fmt.Sprintf("%s is assigned %s", ident.Name, types.ExprString(init)))
+		}
+		return true
+	})
+	return vars
+}
+
+// tableKind names the kind of table, a slice, an array or a map.
+func tableKind(table ast.Expr) string {
+	if lit, ok := table.(*ast.CompositeLit); ok {
+		switch t := lit.Type.(type) {
+		case *ast.ArrayType:
+			if t.Len == nil {
+				return "slice"
+			}
+			return "array"
+		case *ast.MapType:
+			return "map"
+		}
+	}
+	return "table"
+}
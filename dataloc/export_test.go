@@ -0,0 +1,42 @@
+package dataloc
+
+import (
+	"go/ast"
+	"go/token"
+	"path/filepath"
+)
+
+var ReadFile = &readFile
+
+var UnregisterNameOption = unregisterNameOption
+
+var URLPath = urlPath
+
+// ParseCount returns the number of source files parsed so far.
+func ParseCount() int {
+	cache.RLock()
+	defer cache.RUnlock()
+	return cache.parses
+}
+
+// LocateOutsidePackage returns the location of a node of export_test.go, of
+// another package than the caller in the same directory, as if a lookup
+// from the caller had found it there.
+func LocateOutsidePackage() (Location, error) {
+	c, err := callerAt(2)
+	if err != nil {
+		return Location{}, err
+	}
+	var node ast.Node
+	c.fset.Iterate(func(f *token.File) bool {
+		if filepath.Base(f.Name()) == "export_test.go" {
+			node = &ast.Ident{NamePos: token.Pos(f.Base())}
+			return false
+		}
+		return true
+	})
+	if node == nil {
+		return Location{}, ErrNotFound
+	}
+	return c.location(node)
+}
@@ -0,0 +1,208 @@
+package dataloc
+
+import (
+	"fmt"
+	"go/ast"
+	"log"
+)
+
+// Finder locates test cases as the functions of this package do, with its own
+// configuration, eg. for a helper taking the name of a test case:
+//
+//	var finder = dataloc.New(dataloc.WithFuncName("where"))
+//
+//	func where(name string) string {
+//		loc, err := finder.Find(3, name)
+//		if err != nil {
+//			return err.Error()
+//		}
+//		return loc.String()
+//	}
+//	...
+//	t.Errorf("%s: got %d, want %d", where(testcase.name), got, testcase.want)
+//
+// A Finder created by New has its own cache of parsed files and is not
+// affected by the package variables, such as NameTransform, which configure
+// the functions of this package only.
+type Finder struct {
+	// FuncName is the name of a function or method taking the name of a test
+	// case as its first argument, eg. a helper calling Find, whose calls are
+	// looked for along with the ones of this package.
+	FuncName string
+	// PackageName is the name the functions of this package are called with,
+	// eg. "testloc" for a package wrapping this one with the same functions.
+	// By default, it is the name the caller file imports this package as.
+	PackageName string
+	// Logger receives the diagnostics of the lookups instead of the package
+	// Logger.
+	Logger *log.Logger
+	// NameTransform, MatchFieldFold, StrictNames, BasePath and
+	// MaxSearchDepth are as the package variables of the same names are for
+	// the functions of this package.
+	NameTransform  func(string) string
+	MatchFieldFold bool
+	StrictNames    bool
+	BasePath       string
+	MaxSearchDepth int
+
+	// cache holds the files parsed by the Finder; if nil, eg. for a Finder
+	// not created by New, the cache of the package is used
+	cache *fileCache
+}
+
+// Option configures a Finder.
+type Option func(*Finder)
+
+// WithFuncName sets the FuncName of a Finder.
+func WithFuncName(name string) Option {
+	return func(f *Finder) { f.FuncName = name }
+}
+
+// WithPackageName sets the PackageName of a Finder.
+func WithPackageName(name string) Option {
+	return func(f *Finder) { f.PackageName = name }
+}
+
+// WithLogger sets the Logger of a Finder.
+func WithLogger(l *log.Logger) Option {
+	return func(f *Finder) { f.Logger = l }
+}
+
+// WithNameTransform sets the NameTransform of a Finder.
+func WithNameTransform(transform func(string) string) Option {
+	return func(f *Finder) { f.NameTransform = transform }
+}
+
+// WithMatchFieldFold sets the MatchFieldFold of a Finder.
+func WithMatchFieldFold(fold bool) Option {
+	return func(f *Finder) { f.MatchFieldFold = fold }
+}
+
+// WithStrictNames sets the StrictNames of a Finder.
+func WithStrictNames(strict bool) Option {
+	return func(f *Finder) { f.StrictNames = strict }
+}
+
+// WithBasePath sets the BasePath of a Finder.
+func WithBasePath(path string) Option {
+	return func(f *Finder) { f.BasePath = path }
+}
+
+// WithMaxSearchDepth sets the MaxSearchDepth of a Finder.
+func WithMaxSearchDepth(depth int) Option {
+	return func(f *Finder) { f.MaxSearchDepth = depth }
+}
+
+// New returns a Finder configured by opts, with a cache of its own.
+func New(opts ...Option) *Finder {
+	f := &Finder{MaxSearchDepth: defaultMaxSearchDepth, cache: &fileCache{}}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// defaultFinder returns the Finder of the functions of this package, eg. L,
+// configured by the package variables as they are at the time of the call.
+func defaultFinder() *Finder {
+	return &Finder{
+		NameTransform:  NameTransform,
+		MatchFieldFold: MatchFieldFold,
+		StrictNames:    StrictNames,
+		BasePath:       BasePath,
+		MaxSearchDepth: MaxSearchDepth,
+		cache:          cache,
+	}
+}
+
+// Reset clears the files parsed by f, as Reset does for the functions of
+// this package.
+func (f *Finder) Reset() {
+	if f.cache != nil {
+		f.cache.reset()
+	}
+}
+
+// Find returns the location of the test case identified by its name, looked
+// up from the call at the caller frame given by skip, counted as for LSkip:
+// 1 is Find and 2 its caller. The call at that frame must be to one of the
+// functions of this package taking the name, to the Find method of a Finder,
+// eg. "finder.Find(2, testcase.name)", or to the function named FuncName.
+// ErrNotFound is returned if no test case has the name, and ErrAmbiguous if
+// StrictNames is set and several test cases have it.
+func (f *Finder) Find(skip int, name string) (Location, error) {
+	if f.StrictNames {
+		c, err := f.callerAt(skip)
+		if err != nil {
+			return Location{}, err
+		}
+		return c.findUnique(name)
+	}
+
+	l, err := f.locate(name, skip)
+	if err != nil {
+		return Location{}, err
+	}
+	if l == (Location{}) {
+		return Location{}, fmt.Errorf("%w: %q", ErrNotFound, name)
+	}
+	return l, nil
+}
+
+// locate is like the function locate, with the configuration of f.
+func (f *Finder) locate(value string, step int) (Location, error) {
+	c, err := f.callerAt(step + 1)
+	if err != nil {
+		if c != nil && BinaryFallback {
+			return binaryLoc(f.BasePath, c.pc)
+		}
+		return Location{}, err
+	}
+
+	node, err := c.find(value)
+	if err != nil || node == nil {
+		return Location{}, err
+	}
+	return c.location(node)
+}
+
+// callerAt is parseCaller also looking for the calls of FuncName and of
+// Find.
+func (f *Finder) callerAt(step int) (*caller, error) {
+	c, err := f.parseCaller(step + 1)
+	if err != nil {
+		return c, err
+	}
+	c.pkg, c.funcName, c.finder = f.PackageName, f.FuncName, true
+	return c, nil
+}
+
+// isFinderCall matches a call to the function or method named funcName,
+// eg. "check(t, testcase.name, ...)", or to the Find method of a Finder,
+// eg. "finder.Find(2, testcase.name)", where finder is not pkg, the name of
+// this package, and returns it along with its argument giving the name.
+func (c *caller) isFinderCall(n ast.Node, pkg string) (*ast.CallExpr, ast.Expr, bool) {
+	call, ok := n.(*ast.CallExpr)
+	if !ok || len(call.Args) == 0 {
+		return nil, nil, false
+	}
+
+	var name string
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		name = fun.Name
+	case *ast.SelectorExpr:
+		name = fun.Sel.Name
+		if x, ok := fun.X.(*ast.Ident); ok && x.Name == pkg && x.Obj == nil {
+			// a function of this package, matched by isNameFuncCall
+			return nil, nil, false
+		}
+		if c.finder && name == "Find" && len(call.Args) == 2 {
+			return call, call.Args[1], true
+		}
+	}
+	if c.funcName != "" && name == c.funcName {
+		return call, call.Args[0], true
+	}
+	return nil, nil, false
+}
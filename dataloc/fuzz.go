@@ -0,0 +1,128 @@
+package dataloc
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// LocateFuzzSeed returns the location of the call adding values to the seed
+// corpus of the fuzz test calling it, eg.
+//
+//	func FuzzFoo(f *testing.F) {
+//		f.Add("foo", 1)
+//		f.Fuzz(func(t *testing.T, s string, n int) {
+//			loc, err := dataloc.LocateFuzzSeed(s, n)
+//			...
+//		})
+//	}
+//
+// A seed is matched when its arguments are literals equal to values and it
+// is added through the *testing.F parameter of the fuzz test, so that eg.
+// "wg.Add(1)" is not taken as a seed.
+func LocateFuzzSeed(values ...interface{}) (Location, error) {
+	c, err := callerAt(2)
+	if err != nil {
+		return Location{}, err
+	}
+
+	fn := enclosingFunc(c.fset, c.file, c.line)
+	if fn == nil {
+		return Location{}, fmt.Errorf("dataloc: no function found at %s:%d", c.fset.File(c.file.Pos()).Name(), c.line)
+	}
+	f := fuzzParam(fn)
+	if f == nil {
+		return Location{}, fmt.Errorf("dataloc: %s is not a fuzz test taking a *testing.F", fn.Name.Name)
+	}
+
+	var found ast.Node
+	ast.Inspect(fn, func(n ast.Node) bool {
+		if found != nil {
+			return false
+		}
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) != len(values) {
+			return true
+		}
+		if recv, fun, ok := isSelector(call.Fun); !ok || fun != "Add" || recv.Obj != f {
+			return true
+		}
+		for i, arg := range call.Args {
+			if !c.ix.literalEquals(arg, values[i]) {
+				return true
+			}
+		}
+		found = call
+		return false
+	})
+
+	if found == nil {
+		return Location{}, fmt.Errorf("%w: fuzz seed %v", ErrNotFound, values)
+	}
+	return c.location(found)
+}
+
+// fuzzParam returns the object of the *testing.F parameter of fn if fn is a
+// fuzz test, eg. f for "func FuzzFoo(f *testing.F)".
+func fuzzParam(fn *ast.FuncDecl) *ast.Object {
+	if fn.Recv != nil || !strings.HasPrefix(fn.Name.Name, "Fuzz") {
+		return nil
+	}
+	params := fn.Type.Params.List
+	if len(params) != 1 || len(params[0].Names) != 1 {
+		return nil
+	}
+	star, ok := params[0].Type.(*ast.StarExpr)
+	if !ok {
+		return nil
+	}
+	if pkg, name, ok := isSelector(star.X); !ok || pkg.Name != "testing" || name != "F" {
+		return nil
+	}
+	return params[0].Names[0].Obj
+}
+
+// literalEquals reports whether expr is a literal or a constant of value v.
+func (ix *index) literalEquals(expr ast.Expr, v interface{}) bool {
+	switch v := v.(type) {
+	case string:
+		s, ok := stringValue(expr)
+		return ok && s == v
+	case []byte:
+		// []byte("...")
+		call, ok := expr.(*ast.CallExpr)
+		if !ok || len(call.Args) != 1 {
+			return false
+		}
+		if _, ok := call.Fun.(*ast.ArrayType); !ok {
+			return false
+		}
+		s, ok := stringValue(call.Args[0])
+		return ok && s == string(v)
+	case bool:
+		ident, ok := expr.(*ast.Ident)
+		return ok && ident.Name == strconv.FormatBool(v)
+	case float32, float64:
+		lit, ok := expr.(*ast.BasicLit)
+		if !ok || (lit.Kind != token.FLOAT && lit.Kind != token.INT) {
+			return false
+		}
+		f, err := strconv.ParseFloat(lit.Value, 64)
+		return err == nil && f == reflect.ValueOf(v).Float()
+	}
+
+	n, ok := ix.intValue(ix.unconvert(expr))
+	if !ok {
+		return false
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return n == rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return n >= 0 && uint64(n) == rv.Uint()
+	}
+	return false
+}
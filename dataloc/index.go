@@ -0,0 +1,201 @@
+package dataloc
+
+import (
+	"encoding/json"
+	"go/ast"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CaseInfo describes a test case found in a table.
+type CaseInfo struct {
+	Name     string
+	Location Location
+}
+
+// IndexFile returns the test cases of the tables looked up by the
+// dataloc.L() calls in file, in the order of the source.
+// Only test cases named by string literals are returned.
+func IndexFile(file string) ([]CaseInfo, error) {
+	p, err := loadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return indexFile(p, nil), nil
+}
+
+// tableKey identifies the test cases of a table named by key.
+type tableKey struct {
+	table ast.Expr
+	key   string
+}
+
+// indexFile is IndexFile for p, a parsed file. If seen is not nil, the tables
+// in it are left out and those of p added, so that a table looked up from
+// several files, or several times, is indexed once.
+func indexFile(p *parsedFile, seen map[tableKey]bool) []CaseInfo {
+	fset, f, ix := p.fset, p.file, p.ix
+
+	var cases []CaseInfo
+	for _, l := range ix.lookupsIn(f, importName(f)) {
+		if seen != nil && l.table != nil {
+			k := tableKey{l.table, l.key}
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+		}
+		for _, item := range ix.items(l) {
+			name, ok := ix.stringValue(item.name)
+			if !ok {
+				continue
+			}
+			cases = append(cases, CaseInfo{
+				Name:     name,
+				Location: positionToLocation(fset.Position(item.node.Pos())),
+			})
+		}
+	}
+	return cases
+}
+
+// IndexPackage indexes as IndexFile does every _test.go file in dir, which is
+// parsed once for all of them, and returns the
+// test cases keyed by the file as found in their locations, which may be
+// another file than the one looking them up, eg. for a table shared by the
+// files of the package. Each test case is returned once, however many files
+// look it up. Files without test cases are omitted.
+func IndexPackage(dir string) (map[string][]CaseInfo, error) {
+	files, err := parseTestFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	index := map[string][]CaseInfo{}
+	seen := map[tableKey]bool{}
+	for _, p := range files {
+		for _, c := range indexFile(p, seen) {
+			index[c.Location.File] = append(index[c.Location.File], c)
+		}
+	}
+	return index, nil
+}
+
+// StreamIndex is like IndexPackage but writes the test cases to w as they are
+// found, one JSON object per line, eg.
+//
+//	{"name":"foo","file":"foo_test.go","line":12,"column":3}
+//
+// which avoids holding the whole index of large packages in memory: only the
+// test cases of a file are, and the tables already written.
+func StreamIndex(dir string, w io.Writer) error {
+	files, err := parseTestFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	seen := map[tableKey]bool{}
+	for _, p := range files {
+		for _, c := range indexFile(p, seen) {
+			err := enc.Encode(struct {
+				Name   string `json:"name"`
+				File   string `json:"file"`
+				Line   int    `json:"line"`
+				Column int    `json:"column"`
+			}{c.Name, c.Location.File, c.Location.Line, c.Location.Column})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SkipVendor makes IndexPackage and StreamIndex skip the files in a vendor
+// directory, which hold the tests of dependencies rather than of the module.
+var SkipVendor = true
+
+// PathFilter, if not nil, makes IndexPackage and StreamIndex index only the
+// files for which it returns true, eg. to leave out generated sources.
+var PathFilter func(path string) bool
+
+// parseTestFiles returns the files of testFiles, parsed along with the other
+// files of dir at once.
+func parseTestFiles(dir string) ([]*parsedFile, error) {
+	files, err := testFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, len(files))
+	for i, file := range files {
+		paths[i] = filepath.Join(abs, filepath.Base(file))
+	}
+
+	d, err := cache.loadDir(abs, BasePath, nil, paths...)
+	if err != nil {
+		return nil, err
+	}
+	parsed := make([]*parsedFile, len(paths))
+	for i, path := range paths {
+		if parsed[i], err = d.file(path); err != nil {
+			return nil, err
+		}
+	}
+	return parsed, nil
+}
+
+// testFiles returns the _test.go files in dir, filtered by SkipVendor and
+// PathFilter.
+func testFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), "_test.go") {
+			continue
+		}
+		file := filepath.Join(dir, entry.Name())
+		if SkipVendor && isVendored(file) {
+			continue
+		}
+		if PathFilter != nil && !PathFilter(file) {
+			continue
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// isVendored reports whether path is in a vendor directory of its module;
+// the directories above the module root, eg. of a checkout under
+// /home/u/vendor/proj, do not count.
+func isVendored(path string) bool {
+	root, err := ModuleRoot(path)
+	if err != nil {
+		return false
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(root, filepath.Dir(abs))
+	if err != nil {
+		return false
+	}
+	for _, elem := range strings.Split(filepath.ToSlash(rel), "/") {
+		if elem == "vendor" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,668 @@
+package dataloc
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// StrictNames makes Find fail with ErrAmbiguous when several test cases have
+// the name looked up, instead of returning the first of them as L does.
+var StrictNames = false
+
+// ErrAmbiguous is returned by Find when StrictNames is set and several test
+// cases have the name looked up. The error lists their locations.
+var ErrAmbiguous = errors.New("dataloc: ambiguous test case name")
+
+// Find returns the location of the test case identified by its name, or
+// ErrNotFound if none has that name. Unlike L, the column is available.
+// The same restrictions as L apply.
+func Find(name string) (Location, error) {
+	if StrictNames {
+		c, err := callerAt(2)
+		if err != nil {
+			return Location{}, err
+		}
+		return c.findUnique(name)
+	}
+
+	l, err := locate(name, 2)
+	if err != nil {
+		return Location{}, err
+	}
+	if l == (Location{}) {
+		return Location{}, fmt.Errorf("%w: %q", ErrNotFound, name)
+	}
+	return l, nil
+}
+
+// Match is a test case located by its name.
+type Match struct {
+	Location
+	// Exact is false if the test case was found by a heuristic, which may be
+	// mistaken, eg. assuming that an enum's String method returns the name
+	// of its constants.
+	Exact bool
+}
+
+// FindMatch is like Find but also reports whether the test case was found
+// exactly or by a heuristic. The same restrictions as L apply.
+func FindMatch(name string) (Match, error) {
+	c, err := callerAt(2)
+	if err != nil {
+		return Match{}, err
+	}
+	item, l, err := c.findMatch(name)
+	if err != nil {
+		return Match{}, err
+	}
+	if item.node == nil {
+		return Match{}, fmt.Errorf("%w: %q", ErrNotFound, name)
+	}
+	loc, err := c.location(item.node)
+	if err != nil {
+		return Match{}, err
+	}
+	return Match{Location: loc, Exact: !l.inexact}, nil
+}
+
+// findUnique returns the location of the only test case named value, looked
+// up as find does, or ErrAmbiguous listing all of them.
+func (c *caller) findUnique(value string) (Location, error) {
+	var err error
+	for _, l := range c.lookups() {
+		if l.err != nil {
+			if err == nil {
+				err = l.err
+			}
+			continue
+		}
+		nodes := c.ix.findAll(l, value)
+		if len(nodes) == 0 {
+			continue
+		}
+		if len(nodes) == 1 {
+			return c.location(nodes[0])
+		}
+		var locs []string
+		for _, node := range nodes {
+			loc, err := c.location(node)
+			if err != nil {
+				return Location{}, err
+			}
+			locs = append(locs, fmt.Sprintf("%s:%d:%d", loc.File, loc.Line, loc.Column))
+		}
+		return Location{}, fmt.Errorf("%w: %q at %s", ErrAmbiguous, value, strings.Join(locs, ", "))
+	}
+	if err != nil {
+		return Location{}, err
+	}
+	return Location{}, fmt.Errorf("%w: %q", ErrNotFound, value)
+}
+
+// LocateFromFrame returns the location of the test case identified by its
+// name, looked up at the line of fr, a frame captured earlier, eg. by a
+// logging library, rather than at the caller. As the line need not call this
+// package, the name is looked up by the arguments of any call at it, eg.
+//
+//	logger.Error(tc.name) // <- fr
+func LocateFromFrame(fr runtime.Frame, name string) (Location, error) {
+	c, err := defaultFinder().newCaller(fr.PC, fr.File, fr.Line)
+	if err != nil {
+		return Location{}, err
+	}
+	c.anyCall = true
+
+	node, err := c.find(name)
+	if err != nil {
+		return Location{}, err
+	}
+	if node == nil {
+		return Location{}, fmt.Errorf("%w: %q", ErrNotFound, name)
+	}
+	return c.location(node)
+}
+
+// Has reports whether a test case is identified by name, as a guard before
+// locating it. The same restrictions as L apply.
+func Has(name string) bool {
+	c, err := callerAt(2)
+	if err != nil {
+		return false
+	}
+	node, _ := c.find(name)
+	return node != nil
+}
+
+// LocateNth returns the location of the nth (0-based) test case named name,
+// in the order of the source. It disambiguates test cases which
+// intentionally share a name, eg. reruns with different parameters.
+// The same restrictions as L apply.
+func LocateNth(name string, n int) (Location, error) {
+	if n < 0 {
+		return Location{}, fmt.Errorf("dataloc: negative index %d of test case named %q", n, name)
+	}
+	c, err := callerAt(2)
+	if err != nil {
+		return Location{}, err
+	}
+
+	lookups := c.lookups()
+	if len(lookups) == 0 {
+		return Location{}, fmt.Errorf("dataloc: no table of test cases found at %s:%d", c.fset.File(c.file.Pos()).Name(), c.line)
+	}
+
+	var count int
+	for _, l := range lookups {
+		if l.err != nil {
+			return Location{}, l.err
+		}
+		nodes := c.ix.findAll(l, name)
+		if len(nodes) == 0 {
+			continue
+		}
+		if n < len(nodes) {
+			return c.location(nodes[n])
+		}
+		count = len(nodes)
+		break
+	}
+	return Location{}, fmt.Errorf("dataloc: test case #%d named %q not found, %d found", n, name, count)
+}
+
+// LineText returns the location of the test case identified by its name
+// along with the line of source at it, trimmed of surrounding spaces.
+// For a test case spanning multiple lines, its first line is returned.
+// The same restrictions as L apply.
+func LineText(name string) (Location, string, error) {
+	c, err := callerAt(2)
+	if err != nil {
+		return Location{}, "", err
+	}
+
+	node, err := c.find(name)
+	if err != nil {
+		return Location{}, "", err
+	}
+	if node == nil {
+		return Location{}, "", fmt.Errorf("%w: %q", ErrNotFound, name)
+	}
+
+	l, err := c.location(node)
+	if err != nil {
+		return Location{}, "", err
+	}
+
+	// the test case may be in another file of the package than the caller
+	src := c.srcs[c.fset.Position(node.Pos()).Filename]
+	lines := strings.Split(string(src), "\n")
+	if l.Line > len(lines) {
+		return Location{}, "", fmt.Errorf("dataloc: line %d out of range in %s", l.Line, l.File)
+	}
+	return l, strings.TrimSpace(lines[l.Line-1]), nil
+}
+
+// LocateValue returns the location of the expression giving the name of the
+// test case identified by its name, typically the string literal of its
+// name field, rather than the location of the test case itself.
+// The same restrictions as L apply.
+func LocateValue(name string) (Location, error) {
+	c, err := callerAt(2)
+	if err != nil {
+		return Location{}, err
+	}
+
+	item, err := c.findItem(name)
+	if err != nil {
+		return Location{}, err
+	}
+	if item.name == nil {
+		return Location{}, fmt.Errorf("%w: %q", ErrNotFound, name)
+	}
+	return c.location(item.name)
+}
+
+// LocateAuto returns the location of the test case whose first string field
+// is value, in the table ranged over by the innermost loop enclosing the
+// call. It spares naming the field of the test cases, eg.
+//
+//	for _, tc := range []struct {
+//		desc string
+//		in   string
+//	}{
+//		{"empty input", ""},
+//	} {
+//		loc, err := dataloc.LocateAuto("empty input")
+//		...
+//	}
+//
+// Only the first string field is considered, so that a test case whose
+// name comes in a later string field, eg. after its input, is not found.
+func LocateAuto(value string) (Location, error) {
+	c, err := callerAt(2)
+	if err != nil {
+		return Location{}, err
+	}
+
+	var l lookup
+	for _, table := range c.enclosingTables() {
+		if key := c.ix.firstStringField(elemType(table)); key != "" {
+			l = lookup{table: table, key: key, match: c.ix.stringMatcher}
+			break
+		}
+	}
+	if l.table == nil {
+		return Location{}, fmt.Errorf("dataloc: no table of test cases with a string field found at %s:%d", c.fset.File(c.file.Pos()).Name(), c.line)
+	}
+
+	node := c.ix.find(l, value)
+	if node == nil {
+		return Location{}, fmt.Errorf("%w: %q", ErrNotFound, value)
+	}
+	return c.location(node)
+}
+
+// firstStringField returns the name of the first field of type string in
+// the struct type typ, or "" if there is none.
+func (ix *index) firstStringField(typ ast.Expr) string {
+	if ident, ok := typ.(*ast.Ident); ok {
+		typ = ix.objToTypeDecl[ident.Obj]
+	}
+	st, ok := typ.(*ast.StructType)
+	if !ok {
+		return ""
+	}
+	for _, field := range st.Fields.List {
+		if ident, ok := field.Type.(*ast.Ident); ok && ident.Name == "string" && len(field.Names) > 0 {
+			return field.Names[0].Name
+		}
+	}
+	return ""
+}
+
+// enclosingTables returns the tables ranged over by the loops enclosing the
+// caller line, innermost first.
+func (c *caller) enclosingTables() []ast.Expr {
+	fn := enclosingFunc(c.fset, c.file, c.line)
+	if fn == nil {
+		return nil
+	}
+
+	var tables []ast.Expr
+	ast.Inspect(fn, func(n ast.Node) bool {
+		rangeStmt, ok := n.(*ast.RangeStmt)
+		if !ok {
+			return true
+		}
+		if c.fset.Position(rangeStmt.Pos()).Line > c.line || c.line > c.fset.Position(rangeStmt.End()).Line {
+			return false
+		}
+		table := c.ix.tableExpr(rangeStmt.X)
+		if table == nil {
+			// for _, tc := range []testcase{...}
+			table = rangeStmt.X
+		}
+		// ast.Inspect walks outside in
+		tables = append([]ast.Expr{table}, tables...)
+		return true
+	})
+	return tables
+}
+
+// LocateByKeyFields returns the location of the entry of a map keyed by
+// structs whose key has the fields given by pairs, in the table ranged over
+// by a loop enclosing the call, eg.
+//
+//	type key struct{ os, arch string }
+//	testcases := map[key]testcase{
+//		{"linux", "amd64"}: { ... },
+//	}
+//	for k, tc := range testcases {
+//		loc, err := dataloc.LocateByKeyFields(map[string]string{"os": k.os, "arch": k.arch})
+//		...
+//	}
+//
+// The fields of a key which are missing from pairs are not compared.
+func LocateByKeyFields(pairs map[string]string) (Location, error) {
+	c, err := callerAt(2)
+	if err != nil {
+		return Location{}, err
+	}
+
+	for _, table := range c.enclosingTables() {
+		lit, ok := table.(*ast.CompositeLit)
+		if !ok {
+			continue
+		}
+		m, ok := lit.Type.(*ast.MapType)
+		if !ok {
+			continue
+		}
+		for _, elt := range lit.Elts {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if ok && c.ix.keyFieldsEqual(kv.Key, m.Key, pairs) {
+				return c.location(kv)
+			}
+		}
+	}
+	return Location{}, fmt.Errorf("%w: key %v", ErrNotFound, pairs)
+}
+
+// LocateMapIndex returns the location of the nth (0-based) entry of a map
+// with string keys, in the order of its sorted keys, in the table ranged
+// over by the innermost loop enclosing the call, eg.
+//
+//	testcases := map[string]testcase{
+//		"b": { ... },
+//		"a": { ... }, // <- 0
+//	}
+//	for range testcases {
+//		loc, err := dataloc.LocateMapIndex(0)
+//		...
+//	}
+//
+// This gives deterministic positions to test cases presented sorted by name.
+func LocateMapIndex(n int) (Location, error) {
+	c, err := callerAt(2)
+	if err != nil {
+		return Location{}, err
+	}
+
+	for _, table := range c.enclosingTables() {
+		if !isMapLit(table) {
+			continue
+		}
+		type entry struct {
+			key string
+			kv  *ast.KeyValueExpr
+		}
+		var entries []entry
+		for _, elt := range table.(*ast.CompositeLit).Elts {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				continue
+			}
+			if key, ok := c.ix.stringValue(kv.Key); ok {
+				entries = append(entries, entry{key, kv})
+			}
+		}
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].key < entries[j].key
+		})
+		if n < 0 || n >= len(entries) {
+			return Location{}, fmt.Errorf("%w: index %d of %d keys", ErrNotFound, n, len(entries))
+		}
+		return c.location(entries[n].kv)
+	}
+	return Location{}, fmt.Errorf("dataloc: no map of test cases found at %s:%d", c.fset.File(c.file.Pos()).Name(), c.line)
+}
+
+// keyFieldsEqual reports whether key, a struct literal of type typ, has the
+// string fields given by pairs.
+func (ix *index) keyFieldsEqual(key ast.Expr, typ ast.Expr, pairs map[string]string) bool {
+	if ident, ok := key.(*ast.Ident); ok {
+		// a key declared as a variable
+		key = ix.objToVarInit[ident.Obj]
+	}
+	lit, ok := key.(*ast.CompositeLit)
+	if !ok {
+		return false
+	}
+	if lit.Type == nil {
+		// the type of a key may be elided, eg. {"linux", "amd64"}
+		lit = &ast.CompositeLit{Type: typ, Elts: lit.Elts}
+	}
+	for field, value := range pairs {
+		s, ok := ix.stringValue(ix.fieldValue(lit, field))
+		if !ok || s != value {
+			return false
+		}
+	}
+	return true
+}
+
+// LoopLocation returns the location of the innermost for or range loop
+// enclosing the call, typically the loop over the test cases, eg.
+//
+//	for _, tc := range testcases { // <- here
+//		loc, err := dataloc.LoopLocation()
+//		...
+//	}
+func LoopLocation() (Location, error) {
+	c, err := callerAt(2)
+	if err != nil {
+		return Location{}, err
+	}
+
+	fn := enclosingFunc(c.fset, c.file, c.line)
+	if fn == nil {
+		return Location{}, fmt.Errorf("dataloc: no function found at %s:%d", c.fset.File(c.file.Pos()).Name(), c.line)
+	}
+
+	var loop ast.Node
+	ast.Inspect(fn, func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.RangeStmt, *ast.ForStmt:
+		default:
+			return true
+		}
+		if c.fset.Position(n.Pos()).Line > c.line || c.line > c.fset.Position(n.End()).Line {
+			return false
+		}
+		// ast.Inspect walks outside in
+		loop = n
+		return true
+	})
+	if loop == nil {
+		return Location{}, fmt.Errorf("dataloc: no loop found at %s:%d", c.fset.File(c.file.Pos()).Name(), c.line)
+	}
+	return c.location(loop)
+}
+
+// LocateRunArg returns the location of the test case run as the subtest t,
+// found from the name passed to the enclosing t.Run call, eg.
+//
+//	for _, tc := range testcases {
+//		t.Run(tc.name, func(t *testing.T) {
+//			loc, err := dataloc.LocateRunArg(t)
+//			...
+//		})
+//	}
+//
+// which spares a call to L with the name. The table is resolved from the
+// argument of t.Run, and the test case among its rows by t.Name, as the row
+// of the current iteration is only known at run time: it is the one whose
+// name, rewritten as the testing package does, eg. with underscores for
+// spaces, ends t.Name. When the subtest is the rerun of a name, which the
+// testing package suffixes with "#01" and the like, it is located at the
+// first test case of the name.
+func LocateRunArg(t interface{ Name() string }) (Location, error) {
+	c, err := callerAt(2)
+	if err != nil {
+		return Location{}, err
+	}
+
+	run := c.enclosingRun()
+	if run == nil {
+		return Location{}, fmt.Errorf("dataloc: no call to Run found around %s:%d", c.fset.File(c.file.Pos()).Name(), c.line)
+	}
+	l, ok := c.ix.lookupOf(run.Args[0])
+	if !ok {
+		return Location{}, fmt.Errorf("dataloc: unsupported name of subtest %s", types.ExprString(run.Args[0]))
+	}
+	if l.err != nil {
+		return Location{}, l.err
+	}
+
+	items := c.ix.items(l)
+	name := t.Name()
+	if item, ok := c.subtestItem(items, name); ok {
+		return c.location(item.node)
+	}
+	if i := strings.LastIndex(name, "#"); i >= 0 {
+		// a subtest named the same as a previous one, eg. foo#01, unless a
+		// test case is named so, which is matched above
+		if _, err := strconv.Atoi(name[i+1:]); err == nil {
+			if item, ok := c.subtestItem(items, name[:i]); ok {
+				return c.location(item.node)
+			}
+		}
+	}
+	return Location{}, fmt.Errorf("%w: %q", ErrNotFound, name)
+}
+
+// subtestItem returns the first of items whose name, rewritten as the testing
+// package does, ends name, the full name of a subtest such as
+// "TestFoo/with_spaces" or "TestFoo/a/b" for the test case "a/b". The longest
+// name wins, so that "a/b" rather than "b" is the test case of "TestFoo/a/b".
+func (c *caller) subtestItem(items []caseItem, name string) (caseItem, bool) {
+	var found caseItem
+	var length int
+	for _, item := range items {
+		s, ok := c.ix.stringValue(item.name)
+		if !ok {
+			continue
+		}
+		s = subtestName(s)
+		if len(s) > length && strings.HasSuffix(name, "/"+s) {
+			found, length = item, len(s)
+		}
+	}
+	return found, length > 0
+}
+
+// subtestName rewrites name as the testing package does for the name of a
+// subtest, with underscores for spaces and the unprintable characters
+// escaped.
+func subtestName(name string) string {
+	b := make([]byte, 0, len(name))
+	for _, r := range name {
+		switch {
+		case unicode.IsSpace(r):
+			b = append(b, '_')
+		case !strconv.IsPrint(r):
+			s := strconv.QuoteRune(r)
+			b = append(b, s[1:len(s)-1]...)
+		default:
+			b = append(b, string(r)...)
+		}
+	}
+	return string(b)
+}
+
+// enclosingRun returns the innermost call of the form "x.Run(name, func...)"
+// whose function spans the caller line.
+func (c *caller) enclosingRun() *ast.CallExpr {
+	var run *ast.CallExpr
+	ast.Inspect(c.file, func(n ast.Node) bool {
+		if n == nil {
+			return false
+		}
+		if c.fset.Position(n.Pos()).Line > c.line || c.line > c.fset.Position(n.End()).Line {
+			return false
+		}
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) != 2 {
+			return true
+		}
+		if _, fun, ok := isSelector(call.Fun); !ok || fun != "Run" {
+			return true
+		}
+		if _, ok := call.Args[1].(*ast.FuncLit); ok {
+			// ast.Inspect walks outside in
+			run = call
+		}
+		return true
+	})
+	return run
+}
+
+// Candidates returns the locations of all the test cases named name in the
+// caller file, in the order of the source: those of the table looked up as
+// L does, and those of the other tables of the same kind, which name their
+// test cases by the same field, so that a name shared by several tables is
+// noticed. The same restrictions as L apply.
+func Candidates(name string) ([]Location, error) {
+	c, err := callerAt(2)
+	if err != nil {
+		return nil, err
+	}
+
+	var l lookup
+	var found []ast.Node
+	for _, l = range c.lookups() {
+		if l.err != nil {
+			return nil, l.err
+		}
+		if found = c.ix.findAll(l, name); len(found) > 0 {
+			break
+		}
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrNotFound, name)
+	}
+
+	seen := map[ast.Node]bool{}
+	for _, node := range found {
+		seen[node] = true
+	}
+	ast.Inspect(c.file, func(n ast.Node) bool {
+		table, ok := n.(*ast.CompositeLit)
+		if !ok || table == l.table || !c.ix.sameKind(table, l.table) {
+			return true
+		}
+		other := lookup{table: table, key: l.key, match: l.match}
+		for _, node := range c.ix.findAll(other, name) {
+			if !seen[node] {
+				seen[node] = true
+				found = append(found, node)
+			}
+		}
+		return true
+	})
+	sort.Slice(found, func(i, j int) bool { return found[i].Pos() < found[j].Pos() })
+
+	locs := make([]Location, 0, len(found))
+	for _, node := range found {
+		loc, err := c.location(node)
+		if err != nil {
+			return nil, err
+		}
+		locs = append(locs, loc)
+	}
+	return locs, nil
+}
+
+// sameKind reports whether the literal table is a map if other is, or else
+// a slice of structs.
+func (ix *index) sameKind(table *ast.CompositeLit, other ast.Expr) bool {
+	if isMapLit(table) || isMapLit(other) {
+		return isMapLit(table) && isMapLit(other)
+	}
+	t, ok := table.Type.(*ast.ArrayType)
+	if !ok {
+		return false
+	}
+	elem := uninstantiate(unstar(t.Elt))
+	if ident, ok := elem.(*ast.Ident); ok {
+		elem = ix.objToTypeDecl[ident.Obj]
+	}
+	_, ok = elem.(*ast.StructType)
+	return ok
+}
+
+// isMapLit reports whether expr is a map literal.
+func isMapLit(expr ast.Expr) bool {
+	lit, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return false
+	}
+	_, ok = lit.Type.(*ast.MapType)
+	return ok
+}
@@ -0,0 +1,89 @@
+package dataloc
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// StrictModule makes the lookups fail with ErrOutsideModule when the source
+// file of the caller frame is not in the module of the working directory,
+// eg. in the standard library or a dependency, which is the sign of a wrong
+// number of frames given to L3 and the like.
+var StrictModule = false
+
+// ErrOutsideModule is returned when StrictModule is set and the caller frame
+// is outside of the module.
+var ErrOutsideModule = errors.New("dataloc: file outside of the module")
+
+// moduleRoot returns the directory holding the go.mod file of the module of
+// the working directory.
+func moduleRoot() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return findModuleRoot(dir)
+}
+
+// findModuleRoot returns the nearest directory holding a go.mod file, from
+// dir up.
+func findModuleRoot(dir string) (string, error) {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("dataloc: no go.mod found")
+		}
+		dir = parent
+	}
+}
+
+// ModuleRoot returns the directory holding the go.mod file of the module of
+// file, the nearest one from the directory of file up.
+func ModuleRoot(file string) (string, error) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return "", err
+	}
+	return findModuleRoot(filepath.Dir(abs))
+}
+
+// UseModuleRoot sets BasePath to the root of the module of the caller file,
+// eg. in TestMain, so that the locations are the same whether the tests are
+// run from the directory of the package or from the root of the module, eg.
+//
+//	pkg/foo/foo_test.go:12
+//
+// rather than foo_test.go:12 from pkg/foo.
+func UseModuleRoot() error {
+	_, file, _, ok := runtime.Caller(1)
+	if !ok {
+		return fmt.Errorf("dataloc: could not recover caller at skip %d", 1)
+	}
+	root, err := ModuleRoot(file)
+	if err != nil {
+		return err
+	}
+	BasePath = root
+	return nil
+}
+
+// checkInModule returns ErrOutsideModule if file, an absolute path, is not
+// in the module of the working directory.
+func checkInModule(file string) error {
+	root, err := moduleRoot()
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(root, filepath.FromSlash(file))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("%w: %s is not in %s", ErrOutsideModule, file, root)
+	}
+	return nil
+}
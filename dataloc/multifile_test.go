@@ -0,0 +1,29 @@
+package dataloc_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/client9/go-testutil/dataloc"
+)
+
+// The test cases below share their names with the ones in dataloc_test.go,
+// which must never be resolved from here.
+
+func TestL_multipleFiles(t *testing.T) {
+	tests := []struct {
+		name string
+		line int
+	}{
+		{name: "keyed", line: __line__()},
+		{"unkeyed", __line__()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, expected := dataloc.L(test.name), fmt.Sprintf("%s:%d", "multifile_test.go", test.line); got != expected {
+				t.Errorf("expected %q, got %q", expected, got)
+			}
+		})
+	}
+}
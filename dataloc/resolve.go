@@ -0,0 +1,91 @@
+package dataloc
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+)
+
+// Resolved is the test case a dataloc.L() call site resolves to.
+type Resolved struct {
+	// Name is the name passed to dataloc.L(), or empty if it is not known
+	// statically, eg. "dataloc.L(testcase.name)" inside a loop.
+	Name string
+	// Location is the location of the test case, or the zero Location
+	// if Name is empty or no test case has that name.
+	Location Location
+}
+
+// ResolveFile resolves every dataloc.L() call in file to the test case it
+// refers to, keyed by the line of the call.
+// Names are known statically only when given as string literals or
+// constants, which are looked up in the tables iterated by the enclosing
+// function.
+func ResolveFile(file string) (map[int]Resolved, error) {
+	p, err := loadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	fset, f, ix := p.fset, p.file, p.ix
+
+	pkg := importName(f)
+	resolved := map[int]Resolved{}
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+
+		lookups := ix.lookupsIn(fn, pkg)
+		ast.Inspect(fn, func(n ast.Node) bool {
+			call, arg, ok := isNameFuncCall(n, pkg)
+			if !ok {
+				return true
+			}
+
+			var r Resolved
+			if name, ok := ix.stringValue(arg); ok {
+				r.Name = name
+				for _, l := range lookups {
+					if node := ix.find(l, name); node != nil {
+						r.Location = positionToLocation(fset.Position(node.Pos()))
+						break
+					}
+				}
+			}
+			resolved[fset.Position(call.Pos()).Line] = r
+			return true
+		})
+	}
+
+	return resolved, nil
+}
+
+// LocateInBlob returns the location of the test case named name in content,
+// the source of filename, eg. as given by "git show rev:file" for a past
+// revision. The test case is looked up in the tables iterated by the
+// dataloc.L() calls of content, whatever the file on disk holds.
+func LocateInBlob(content []byte, filename, name string) (Location, error) {
+	fset := token.NewFileSet()
+	f, err := parseFile(fset, BasePath, filename, content)
+	if err != nil {
+		return Location{}, err
+	}
+
+	ix := newIndex(f)
+	for _, l := range ix.lookupsIn(f, importName(f)) {
+		if l.err != nil {
+			if err == nil {
+				err = l.err
+			}
+			continue
+		}
+		if node := ix.find(l, name); node != nil {
+			return positionToLocation(fset.Position(node.Pos())), nil
+		}
+	}
+	if err != nil {
+		return Location{}, err
+	}
+	return Location{}, fmt.Errorf("%w: %q", ErrNotFound, name)
+}
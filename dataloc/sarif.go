@@ -0,0 +1,57 @@
+package dataloc
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+)
+
+// sarifResult is a SARIF 2.1.0 result object with a single location.
+type sarifResult struct {
+	RuleID  string `json:"ruleId"`
+	Message struct {
+		Text string `json:"text"`
+	} `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+		Region struct {
+			StartLine   int `json:"startLine"`
+			StartColumn int `json:"startColumn"`
+		} `json:"region"`
+	} `json:"physicalLocation"`
+}
+
+// SARIFResult returns a SARIF result object, as JSON, reporting message under
+// the rule ruleID at the test case identified by its name, eg.
+//
+//	{"ruleId":"test-failure","message":{"text":"..."},"locations":[{
+//		"physicalLocation":{"artifactLocation":{"uri":"foo_test.go"},
+//		"region":{"startLine":12,"startColumn":3}}}]}
+//
+// so that failing test cases can be reported to code scanning tools.
+// The uri is the file of the test case, with forward slashes.
+// The same restrictions as L apply.
+func SARIFResult(name, ruleID, message string) ([]byte, error) {
+	l, err := locate(name, 2)
+	if err != nil {
+		return nil, err
+	}
+	if l == (Location{}) {
+		return nil, fmt.Errorf("%w: %q", ErrNotFound, name)
+	}
+
+	var loc sarifLocation
+	loc.PhysicalLocation.ArtifactLocation.URI = filepath.ToSlash(l.File)
+	loc.PhysicalLocation.Region.StartLine = l.Line
+	loc.PhysicalLocation.Region.StartColumn = l.Column
+
+	r := sarifResult{RuleID: ruleID, Locations: []sarifLocation{loc}}
+	r.Message.Text = message
+	return json.Marshal(r)
+}
@@ -0,0 +1,22 @@
+package dataloc_test
+
+// sharedCases is iterated by TestL_tableInAnotherFile in dataloc_test.go.
+
+type sharedCase struct {
+	name string
+	line int
+}
+
+var sharedCases = []sharedCase{
+	{name: "keyed", line: __line__()},
+	{"unkeyed", __line__()},
+}
+
+// swapName is called by TestL_funcInAnotherFile in dataloc_test.go; being
+// a function, a call of it is not a conversion to strip.
+func swapName(name string) string {
+	if name == "keyed" {
+		return "unkeyed"
+	}
+	return "keyed"
+}
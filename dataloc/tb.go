@@ -0,0 +1,32 @@
+package dataloc
+
+import "testing"
+
+// Locate logs the location of the test case identified by its name when t
+// fails, as
+//
+//	case "name" defined at file:line
+//
+// by a cleanup registered on t, so that it is quiet on success, eg.
+//
+//	for _, testcase := range testcases {
+//		t.Run(testcase.name, func(t *testing.T) {
+//			dataloc.Locate(t, testcase.name)
+//			...
+//		})
+//	}
+//
+// The test case is located when Locate is called. The same restrictions as
+// L apply.
+func Locate(t testing.TB, name string) {
+	t.Helper()
+	s := "(unknown)"
+	if l, err := locate(name, 2); err == nil && l != (Location{}) {
+		s = l.String()
+	}
+	t.Cleanup(func() {
+		if t.Failed() {
+			t.Logf("case %q defined at %s", name, s)
+		}
+	})
+}
@@ -0,0 +1,13 @@
+package fixture
+
+import "github.com/client9/go-testutil/dataloc"
+
+var cycleA = cycleB
+var cycleB = cycleA
+
+func cycle() {
+	for _, test := range cycleA {
+		_ = dataloc.L(test.name)
+		_ = dataloc.L("a")
+	}
+}
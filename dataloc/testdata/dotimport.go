@@ -0,0 +1,25 @@
+package fixture
+
+import (
+	"testing"
+
+	. "github.com/client9/go-testutil/dataloc"
+)
+
+func TestDotImport(t *testing.T) {
+	tests := []struct {
+		name string
+	}{
+		{"a"},
+		{"b"},
+	}
+
+	for _, test := range tests {
+		t.Log(L(test.name))
+	}
+
+	t.Log(L("b"))
+
+	L := func(name string) string { return name }
+	t.Log(L("a"))
+}
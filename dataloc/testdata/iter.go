@@ -0,0 +1,61 @@
+package fixture
+
+import (
+	"iter"
+	"testing"
+
+	"github.com/client9/go-testutil/dataloc"
+)
+
+type testcase struct {
+	name string
+}
+
+var testcases = []testcase{
+	{"a"},
+	{"b"},
+}
+
+func casesSeq() iter.Seq[testcase] {
+	return func(yield func(testcase) bool) {
+		for _, tc := range testcases {
+			if !yield(tc) {
+				return
+			}
+		}
+	}
+}
+
+func yieldCases(yield func(int, testcase) bool) {
+	for i, tc := range testcases {
+		if !yield(i, tc) {
+			return
+		}
+	}
+}
+
+func TestSeq(t *testing.T) {
+	for tc := range casesSeq() {
+		t.Log(dataloc.L(tc.name))
+	}
+	t.Log(dataloc.L("a"))
+}
+
+func TestSeq2(t *testing.T) {
+	for _, tc := range yieldCases {
+		t.Log(dataloc.L(tc.name))
+	}
+	t.Log(dataloc.L("b"))
+}
+
+func TestFuncLit(t *testing.T) {
+	seq := func(yield func(testcase) bool) {
+		for _, tc := range testcases {
+			yield(tc)
+		}
+	}
+	for tc := range seq {
+		t.Log(dataloc.L(tc.name))
+	}
+	t.Log(dataloc.L("b"))
+}
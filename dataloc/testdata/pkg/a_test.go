@@ -0,0 +1,20 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/client9/go-testutil/dataloc"
+)
+
+func TestA(t *testing.T) {
+	tests := []struct {
+		name string
+	}{
+		{"a1"},
+		{"a2"},
+	}
+
+	for _, test := range tests {
+		t.Log(dataloc.L(test.name))
+	}
+}
@@ -0,0 +1,23 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/client9/go-testutil/dataloc"
+)
+
+var tests = map[string]struct{}{
+	"b1": {},
+}
+
+func TestB(t *testing.T) {
+	for name := range tests {
+		t.Log(dataloc.L(name))
+	}
+}
+
+func TestBAgain(t *testing.T) {
+	for name := range tests {
+		t.Log(dataloc.L(name))
+	}
+}
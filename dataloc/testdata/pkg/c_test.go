@@ -0,0 +1,19 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/client9/go-testutil/dataloc"
+)
+
+func TestC(t *testing.T) {
+	for _, test := range sharedTests {
+		t.Log(dataloc.L(test.name))
+	}
+}
+
+func TestCAgain(t *testing.T) {
+	for _, test := range sharedTests {
+		t.Log(dataloc.L(test.name))
+	}
+}
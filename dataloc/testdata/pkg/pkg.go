@@ -0,0 +1,7 @@
+package pkg
+
+var notTests = []struct {
+	name string
+}{
+	{"ignored"},
+}
@@ -0,0 +1,7 @@
+package pkg
+
+var sharedTests = []struct {
+	name string
+}{
+	{"s1"},
+}
@@ -0,0 +1,26 @@
+package fixture
+
+import (
+	"testing"
+
+	"github.com/client9/go-testutil/dataloc"
+)
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name string
+	}{
+		{"a"},
+		{"b"},
+	}
+
+	for _, test := range tests {
+		t.Log(dataloc.L(test.name))
+	}
+
+	t.Log(dataloc.L("b"))
+	t.Log(dataloc.L("missing"))
+	t.Log(dataloc.L(resolveA))
+}
+
+const resolveA = "a"